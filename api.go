@@ -1,7 +1,10 @@
 package gogo_boy
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 )
 
@@ -13,11 +16,14 @@ import (
 
 type ConfigureInfo struct {
 	AppId      string
-	AppGroupId string
+	AppGroupId string // Optional when APIKey is set
+	APIKey     string // Sent as a bearer token if present
+	BaseURL    string // Defaults to DefaultBaseURL
 }
 
 type Client struct {
 	appGroupId string
+	transport  *Transport
 }
 
 type AppClient struct {
@@ -26,19 +32,43 @@ type AppClient struct {
 	appId string
 }
 
-func NewClient(appGroupId string) *Client {
+// Options configure the transport shared by every request built from this
+// client, e.g. NewClient(appGroupId, WithBaseURL(ClusterUS03))
+func NewClient(appGroupId string, opts ...ClientOption) *Client {
 	client := &Client{
 		appGroupId: appGroupId,
+		transport:  NewTransport(opts...),
 	}
 
 	return client
 }
 
+// Build a client and app client in one go from a ConfigureInfo, options are
+// applied after the configured values so they can override them
+func NewAppClientFromConfig(info ConfigureInfo, opts ...ClientOption) *AppClient {
+	configured := []ClientOption{}
+	if info.APIKey != "" {
+		configured = append(configured, WithAPIKey(info.APIKey))
+	}
+	if info.BaseURL != "" {
+		configured = append(configured, WithBaseURL(info.BaseURL))
+	}
+
+	return NewClient(info.AppGroupId, append(configured, opts...)...).NewAppClient(info.AppId)
+}
+
+func (c *Client) Transport() *Transport {
+	return c.transport
+}
+
 type TrackRequest struct {
 	AppGroupId string
 	AppId      string
 	ExternalId string
 
+	// Who the request is about when it isn't ExternalId, see NewTrackRequestFor
+	Identifier Identifier
+
 	// Unlike the RawTrackRequest, we're only
 	// considering one user which will only ever
 	// use one attribute
@@ -48,13 +78,23 @@ type TrackRequest struct {
 
 	PurchaseEvents []*PurchaseEvent
 	Events         []*Event
+
+	// Not serialized, a request that was unmarshalled posts with the defaults
+	transport *Transport
 }
 
 type CampaignTriggerRequest struct {
 	AppGroupId string
 	CampaignId string
 
+	SendId            string // Optional, see Client.CreateSendId
+	Broadcast         bool
+	Audience          Audience
+	TriggerProperties map[string]interface{}
+
 	Recipients []RawCampaignRecipient
+
+	transport *Transport
 }
 
 func (c *Client) NewAppClient(appId string) *AppClient {
@@ -71,6 +111,7 @@ func (c *AppClient) NewTrackRequest(externalId string) *TrackRequest {
 		Attributes:          map[string]interface{}{},
 		PushTokenAttributes: []string{},
 		ExternalId:          externalId,
+		transport:           c.transport,
 	}
 }
 
@@ -79,6 +120,7 @@ func (c *Client) NewCampaignTriggerRequest(campaignId string) *CampaignTriggerRe
 		AppGroupId: c.appGroupId,
 		CampaignId: campaignId,
 		Recipients: []RawCampaignRecipient{},
+		transport:  c.transport,
 	}
 }
 
@@ -122,30 +164,70 @@ func (tr *TrackRequest) SetCustomValueAttribute(name string, value interface{})
 	tr.Attributes[name] = value
 }
 
-func (tr *TrackRequest) Post() error {
+func (tr *TrackRequest) Post() (*TrackResponse, error) {
+	return tr.PostContext(context.Background())
+}
+
+// Same as Post but aborts when ctx is done. If ctx is cancelled after the
+// track request went through, the push token deletion is skipped and the
+// context's error is returned.
+//
+// The response is returned whenever the track request itself went through,
+// even if deleting push tokens failed afterwards.
+func (tr *TrackRequest) PostContext(ctx context.Context) (*TrackResponse, error) {
+	rt, err := tr.rawTrackRequest()
+	if err != nil {
+		return nil, err
+	}
+
+	// Run the regular track requests first
+	res, err := RawPostTrackRequestContext(ctx, tr.transport, rt)
+	if err != nil {
+		return nil, err
+	}
+
+	// Now run the push token deletions if there are any
+	if len(tr.DeletePushTokenAttributes) > 0 {
+		if err := ctx.Err(); err != nil {
+			return res, fmt.Errorf("PostTrackRequest was aborted before deleting push tokens: %w", err)
+		}
+
+		dr := &RawPushTokenDeleteRequest{
+			AppGroupId: tr.AppGroupId,
+			PushTokens: tr.rawDeletePushTokens(),
+		}
+		err = RawPostDeletePushTokenRequestContext(ctx, tr.transport, dr)
+		if err != nil {
+			return res, err
+		}
+	}
+
+	return res, nil
+}
+
+// Builds the raw request for this one user, shared with the Batcher
+func (tr *TrackRequest) rawTrackRequest() (*RawTrackRequest, error) {
 	rt := &RawTrackRequest{
 		AppGroupId: tr.AppGroupId,
 		Attributes: []RawAttributesInfo{
 			RawAttributesInfo{
-				CustomAttributes: map[string]interface{}{},
-				PushTokens:       []RawPushTokenInfo{},
+				PushTokens: []RawPushTokenInfo{},
 			},
 		},
 		Purchases: []RawPurchaseInfo{},
 	}
 
-	rt.Attributes[0].ExternalId = tr.ExternalId
+	id := tr.identifier()
+	if err := id.validate(); err != nil {
+		return nil, fmt.Errorf("PostTrackRequest for (%s) failed: %s", id, err)
+	}
 
-	for k, v := range tr.Attributes {
-		switch k {
-		case "first_name":
-			rt.Attributes[0].FirstName = v.(string)
-		case "email":
-			rt.Attributes[0].Email = v.(string)
-		default:
-			rt.Attributes[0].CustomAttributes[k] = v
-		}
+	custom, err := applyStandardAttributes(&rt.Attributes[0], tr.Attributes)
+	if err != nil {
+		return nil, fmt.Errorf("PostTrackRequest for (%s) failed: %s", id, err)
 	}
+	rt.Attributes[0].CustomAttributes = custom
+	rt.Attributes[0].setIdentifier(id)
 
 	for _, pt := range tr.PushTokenAttributes {
 		//rt.Attributes[0].PushTokenImport = true
@@ -156,42 +238,39 @@ func (tr *TrackRequest) Post() error {
 	}
 
 	for _, pt := range tr.PurchaseEvents {
+		if err := pt.RawPurchaseInfo.validate(); err != nil {
+			return nil, fmt.Errorf("PostTrackRequest for (%s) failed: purchase %q: %s", id, pt.ProductId, err)
+		}
+		if err := pt.Properties.validate(reservedPurchasePropertyKeys); err != nil {
+			return nil, fmt.Errorf("PostTrackRequest for (%s) failed: purchase %q: %s", id, pt.ProductId, err)
+		}
 		rpi := pt.RawPurchaseInfo
-		rpi.ExternalId = tr.ExternalId
+		rpi.setIdentifier(id)
 		rt.Purchases = append(rt.Purchases, rpi)
 	}
 
 	for _, pt := range tr.Events {
+		if err := pt.Properties.validate(reservedEventPropertyKeys); err != nil {
+			return nil, fmt.Errorf("PostTrackRequest for (%s) failed: event %q: %s", id, pt.Name, err)
+		}
 		rpi := pt.RawEventInfo
-		rpi.ExternalId = tr.ExternalId
+		rpi.setIdentifier(id)
 		rt.Events = append(rt.Events, rpi)
 	}
 
-	// Run the regular track requests first
-	err := RawPostTrackRequest(rt)
-	if err != nil {
-		return err
-	}
-
-	// Now run the push token deletions if there are any
-	if len(tr.DeletePushTokenAttributes) > 0 {
-		dr := &RawPushTokenDeleteRequest{
-			PushTokens: []RawPushTokenInfo{},
-		}
+	return rt, nil
+}
 
-		for _, pt := range tr.DeletePushTokenAttributes {
-			dr.PushTokens = append(dr.PushTokens, RawPushTokenInfo{
-				Token: pt,
-				AppId: tr.AppId,
-			})
-		}
-		err = RawPostDeletePushTokenRequest(dr)
-		if err != nil {
-			return err
-		}
+func (tr *TrackRequest) rawDeletePushTokens() []RawPushTokenInfo {
+	tokens := []RawPushTokenInfo{}
+	for _, pt := range tr.DeletePushTokenAttributes {
+		tokens = append(tokens, RawPushTokenInfo{
+			Token: pt,
+			AppId: tr.AppId,
+		})
 	}
 
-	return nil
+	return tokens
 }
 
 type PurchaseEvent struct {
@@ -210,14 +289,30 @@ func (e *PurchaseEvent) SetCurrencyUSD() {
 	e.Currency = "USD"
 }
 
+// The price is sent with the fewest digits that still read back as val, use
+// SetPriceDecimal or SetPriceMinorUnits if val can't be represented exactly
 func (e *PurchaseEvent) SetPrice(val float32) {
-	e.Price = val
+	e.Price = json.Number(strconv.FormatFloat(float64(val), 'f', -1, 32))
 }
 
 func (e *PurchaseEvent) SetQuantity(val int) {
 	e.Quantity = val
 }
 
+// Same as SetQuantity but refuses anything that isn't between 1 and
+// MaxPurchaseQuantity instead of failing when the request is posted
+func (e *PurchaseEvent) SetQuantityChecked(val int) error {
+	if val == 0 {
+		return fmt.Errorf("quantity 0 is not between 1 and %d", MaxPurchaseQuantity)
+	}
+	if err := validateQuantity(val); err != nil {
+		return err
+	}
+
+	e.Quantity = val
+	return nil
+}
+
 func (e *PurchaseEvent) SetTime(time time.Time) {
 	e.Time = time.UTC().Format("2006-01-02T15:04:05")
 }
@@ -248,16 +343,127 @@ func (ctr *CampaignTriggerRequest) AddRecipient(externalId string, triggerProper
 }
 
 func (ctr *CampaignTriggerRequest) Post() error {
-	rt := &RawCampaignTriggerRequest{
-		AppGroupId: ctr.AppGroupId,
-		CampaignId: ctr.CampaignId,
-		Recipients: ctr.Recipients,
+	return ctr.PostContext(context.Background())
+}
+
+// Same as Post but aborts when ctx is done
+func (ctr *CampaignTriggerRequest) PostContext(ctx context.Context) error {
+	if err := ctr.validate(); err != nil {
+		return err
 	}
 
-	if lr := len(rt.Recipients); lr > 50 {
-		return fmt.Errorf("Tried to post a CampaignTriggerRequest for the [AppBoyCampaign](campaign_id: %s) but there were %d recipients which exceeds the maximum of 50 per request.  You will need to break your campaign trigger requests up into multiple requests in order to send more than 50 recipients", rt.CampaignId, lr)
+	rt := ctr.rawCampaignTriggerRequest(ctr.Recipients)
+
+	if lr := len(rt.Recipients); lr > MaxCampaignTriggerRecipients {
+		return fmt.Errorf("Tried to post a CampaignTriggerRequest for the [AppBoyCampaign](campaign_id: %s) but there were %d recipients which exceeds the maximum of %d per request.  You will need to break your campaign trigger requests up into multiple requests (or use PostAll) in order to send more than %d recipients", rt.CampaignId, lr, MaxCampaignTriggerRecipients, MaxCampaignTriggerRecipients)
 	}
 
-	err := RawPostCampaignTriggerRequest(rt)
+	err := RawPostCampaignTriggerRequestContext(ctx, ctr.transport, rt)
 	return err
 }
+
+func (ctr *CampaignTriggerRequest) validate() error {
+	if ctr.Broadcast && len(ctr.Recipients) > 0 {
+		return fmt.Errorf("CampaignTriggerRequest for the [AppBoyCampaign](campaign_id: %s) can't broadcast and list recipients at the same time", ctr.CampaignId)
+	}
+	if !ctr.Broadcast && len(ctr.Recipients) == 0 {
+		return fmt.Errorf("CampaignTriggerRequest for the [AppBoyCampaign](campaign_id: %s) has no recipients, set Broadcast to send to the whole audience", ctr.CampaignId)
+	}
+	if ctr.Audience != nil {
+		if err := ctr.Audience.validate(); err != nil {
+			return fmt.Errorf("CampaignTriggerRequest for the [AppBoyCampaign](campaign_id: %s) has an invalid audience: %s", ctr.CampaignId, err)
+		}
+	}
+	if len(ctr.SendId) > MaxSendIdLength {
+		return fmt.Errorf("CampaignTriggerRequest for the [AppBoyCampaign](campaign_id: %s) has a send_id longer than %d characters", ctr.CampaignId, MaxSendIdLength)
+	}
+
+	return nil
+}
+
+func (ctr *CampaignTriggerRequest) rawCampaignTriggerRequest(recipients []RawCampaignRecipient) *RawCampaignTriggerRequest {
+	return &RawCampaignTriggerRequest{
+		AppGroupId:        ctr.AppGroupId,
+		CampaignId:        ctr.CampaignId,
+		SendId:            ctr.SendId,
+		Broadcast:         ctr.Broadcast,
+		Audience:          ctr.Audience,
+		TriggerProperties: ctr.TriggerProperties,
+		Recipients:        recipients,
+	}
+}
+
+// One request's worth of recipients sent by PostAll
+type CampaignTriggerChunk struct {
+	Index      int
+	Recipients []RawCampaignRecipient
+	Err        error
+}
+
+type CampaignTriggerResult struct {
+	Chunks []CampaignTriggerChunk
+}
+
+func (r *CampaignTriggerResult) Failed() []CampaignTriggerChunk {
+	failed := []CampaignTriggerChunk{}
+	for _, chunk := range r.Chunks {
+		if chunk.Err != nil {
+			failed = append(failed, chunk)
+		}
+	}
+
+	return failed
+}
+
+// Everyone who may not have been triggered, handy for trying again
+func (r *CampaignTriggerResult) FailedRecipients() []RawCampaignRecipient {
+	recipients := []RawCampaignRecipient{}
+	for _, chunk := range r.Failed() {
+		recipients = append(recipients, chunk.Recipients...)
+	}
+
+	return recipients
+}
+
+// Unlike Post, sends any number of recipients by splitting them into requests
+// of 50. Up to concurrency requests are in flight at once, anything below 2
+// sends them one after the other. Every chunk is attempted even if an earlier
+// one failed; the error summarises the failures and the result says which
+// chunks they were.
+func (ctr *CampaignTriggerRequest) PostAll(concurrency int) (*CampaignTriggerResult, error) {
+	return ctr.PostAllContext(context.Background(), concurrency)
+}
+
+// Same as PostAll but aborts when ctx is done, chunks that weren't sent yet
+// fail with the context's error
+func (ctr *CampaignTriggerRequest) PostAllContext(ctx context.Context, concurrency int) (*CampaignTriggerResult, error) {
+	if ctr.Broadcast {
+		return nil, fmt.Errorf("PostAll for the [AppBoyCampaign](campaign_id: %s) can't split up a broadcast, use Post", ctr.CampaignId)
+	}
+	if err := ctr.validate(); err != nil {
+		return nil, err
+	}
+
+	ranges := chunkRanges(len(ctr.Recipients), MaxCampaignTriggerRecipients)
+	res := &CampaignTriggerResult{}
+	for i, r := range ranges {
+		res.Chunks = append(res.Chunks, CampaignTriggerChunk{
+			Index:      i,
+			Recipients: ctr.Recipients[r[0]:r[1]],
+		})
+	}
+
+	errs := postChunks(ctx, len(res.Chunks), concurrency, func(ctx context.Context, i int) error {
+		rt := ctr.rawCampaignTriggerRequest(res.Chunks[i].Recipients)
+		return RawPostCampaignTriggerRequestContext(ctx, ctr.transport, rt)
+	})
+	for i, err := range errs {
+		res.Chunks[i].Err = err
+	}
+
+	if failed := res.Failed(); len(failed) > 0 {
+		return res, fmt.Errorf("PostAll for the [AppBoyCampaign](campaign_id: %s) failed for %d of %d chunks (%d recipients), the first error was: %w", ctr.CampaignId, len(failed), len(res.Chunks), len(res.FailedRecipients()), failed[0].Err)
+	}
+
+	return res, nil
+}
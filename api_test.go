@@ -1,10 +1,14 @@
 package gogo_boy
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -33,11 +37,12 @@ func TestAPI(t *testing.T) {
 
 		externalId := "holah"
 		a := appClient.NewTrackRequest(externalId)
-		err := a.Post()
+		_, err := a.Post()
 
-		err = a.Post()
+		res, err := a.Post()
 		checkErr(err)
 		So(err, ShouldEqual, nil)
+		So(res.AttributesProcessed, ShouldEqual, 1)
 
 		// Root
 		So(request["app_group_id"], ShouldEqual, "foo")
@@ -61,9 +66,9 @@ func TestAPI(t *testing.T) {
 		a.SetEmail("test@test.com")
 		a.SetCustomValueAttribute("baz", "bar")
 		a.AddPushToken("apple-token")
-		err := a.Post()
+		_, err := a.Post()
 
-		err = a.Post()
+		_, err = a.Post()
 		checkErr(err)
 		So(err, ShouldEqual, nil)
 
@@ -98,7 +103,7 @@ func TestAPI(t *testing.T) {
 		pEvent.SetTime(time.Unix(0, 0))
 		a.AddEvent(pEvent)
 
-		err := a.Post()
+		_, err := a.Post()
 		checkErr(err)
 		So(err, ShouldEqual, nil)
 
@@ -132,7 +137,7 @@ func TestAPI(t *testing.T) {
 		eventB.SetTime(time.Unix(0, 0))
 		a.AddEvent(eventB)
 
-		err := a.Post()
+		_, err := a.Post()
 		checkErr(err)
 		So(err, ShouldEqual, nil)
 
@@ -175,7 +180,8 @@ func TestAPI(t *testing.T) {
 		eventC.SetCurrencyUSD()
 		a.AddEvent(eventC)
 
-		checkErr(a.Post())
+		_, err := a.Post()
+		checkErr(err)
 		requestA := request
 		request = nil
 
@@ -186,7 +192,8 @@ func TestAPI(t *testing.T) {
 		err = json.Unmarshal(res, &req)
 		checkErr(err)
 
-		checkErr(req.Post())
+		_, err = req.Post()
+		checkErr(err)
 		requestB := request
 
 		So(requestA, ShouldNotEqual, nil)
@@ -195,7 +202,7 @@ func TestAPI(t *testing.T) {
 		So(len(req.Attributes), ShouldEqual, 3)
 		So(len(req.Events), ShouldEqual, 2)
 		So(len(req.PurchaseEvents), ShouldEqual, 1)
-		So(req.PurchaseEvents[0].Price, ShouldEqual, 1)
+		So(req.PurchaseEvents[0].Price, ShouldEqual, json.Number("1"))
 		So(req.PurchaseEvents[0].Time, ShouldEqual, "1970-01-02T00:00:00")
 		So(req.Events[0].Name, ShouldEqual, "blah")
 		So(req.Events[0].Time, ShouldEqual, "1970-01-01T23:59:59")
@@ -217,7 +224,7 @@ func TestAPI(t *testing.T) {
 		a.SetCustomValueAttribute("foo", "bar")
 
 		// This will fail because it hits app boys servers
-		err := a.Post()
+		_, err := a.Post()
 		So(err, ShouldNotEqual, nil)
 	})
 
@@ -237,7 +244,7 @@ func TestAPI(t *testing.T) {
 		a.SetCustomValueAttribute("foo", "bar")
 
 		// This will fail because it hits app boys servers
-		err := a.Post()
+		_, err := a.Post()
 		So(err, ShouldNotEqual, nil)
 	})
 
@@ -257,9 +264,9 @@ func TestAPI(t *testing.T) {
 		a.SetCustomValueAttribute("baz", "bar")
 		a.AddPushToken("apple-token")
 		a.RemovePushToken("apple-token2")
-		err := a.Post()
+		_, err := a.Post()
 
-		err = a.Post()
+		_, err = a.Post()
 		checkErr(err)
 		So(err, ShouldEqual, nil)
 
@@ -277,6 +284,7 @@ func TestAPI(t *testing.T) {
 		So(pushTokenAttribute["token"], ShouldEqual, "apple-token")
 
 		// Delete push tokens
+		So(deletePushTokenRequest["app_group_id"], ShouldEqual, "foo")
 		deletePushTokenAttributes := deletePushTokenRequest["push_tokens"].([]interface{})
 		deletePushTokenAttribute := deletePushTokenAttributes[0].(map[string]interface{})
 		So(deletePushTokenAttribute["app_id"], ShouldEqual, "blah")
@@ -382,4 +390,112 @@ func TestAPI(t *testing.T) {
 		So(likeCount, ShouldEqual, 31)
 	})
 
+	Convey("Can cancel a track request with a context", t, func() {
+		var calls int
+		rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			calls++
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go cancel()
+
+		a := NewClient("foo", WithRoundTripper(rt)).NewAppClient("blah").NewTrackRequest("holah")
+		_, err := a.PostContext(ctx)
+		So(err, ShouldNotEqual, nil)
+		So(errors.Is(err, context.Canceled), ShouldEqual, true)
+		So(calls, ShouldEqual, 1)
+	})
+
+	Convey("Aborts push token deletion if cancelled after the track request", t, func() {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		var paths []string
+		rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			paths = append(paths, req.URL.Path)
+
+			// Cancel once app-boy has accepted the track request
+			cancel()
+			return newStubResponse(201, getFixtureWithPath("track_success_res.json")), nil
+		})
+
+		a := NewClient("foo", WithRoundTripper(rt)).NewAppClient("blah").NewTrackRequest("holah")
+		a.RemovePushToken("apple-token2")
+		_, err := a.PostContext(ctx)
+		So(err, ShouldNotEqual, nil)
+		So(errors.Is(err, context.Canceled), ShouldEqual, true)
+		So(strings.Contains(err.Error(), "push tokens"), ShouldEqual, true)
+		So(paths, ShouldResemble, []string{TrackPath})
+	})
+
+	Convey("Can cancel a campaign trigger with a context", t, func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		a := client.NewCampaignTriggerRequest("my-campaign-id")
+		a.AddRecipient("4900", nil)
+		err := a.PostContext(ctx)
+		So(errors.Is(err, context.Canceled), ShouldEqual, true)
+	})
+
+	Convey("PostAll splits recipients into chunks of 50", t, func() {
+		before()
+		defer after()
+
+		// Mock request to app-boy
+		sizes := []int{}
+		MockCampaignTriggerSuccess(func(_request map[string]interface{}) {
+			sizes = append(sizes, len(_request["recipients"].([]interface{})))
+		})
+
+		a := client.NewCampaignTriggerRequest("my-campaign-id")
+		for i := 0; i < 120; i++ {
+			a.AddRecipient(fmt.Sprintf("%d", i), nil)
+		}
+		res, err := a.PostAll(1)
+
+		checkErr(err)
+		So(err, ShouldEqual, nil)
+		So(sizes, ShouldResemble, []int{50, 50, 20})
+		So(len(res.Chunks), ShouldEqual, 3)
+		So(res.Chunks[2].Recipients[0].ExternalId, ShouldEqual, "100")
+		So(len(res.Failed()), ShouldEqual, 0)
+	})
+
+	Convey("PostAll reports which chunks failed", t, func() {
+		var mu sync.Mutex
+		var calls int
+		rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			var request RawCampaignTriggerRequest
+			checkErr(json.NewDecoder(req.Body).Decode(&request))
+
+			mu.Lock()
+			calls++
+			mu.Unlock()
+
+			// The second chunk starts at recipient 50
+			if request.Recipients[0].ExternalId == "50" {
+				return newStubResponse(400, getFixtureWithPath("triggered_campaign_res_err.json")), nil
+			}
+			return newStubResponse(201, getFixtureWithPath("triggered_campaign_res.json")), nil
+		})
+
+		a := NewClient("foo", WithRoundTripper(rt)).NewCampaignTriggerRequest("my-campaign-id")
+		for i := 0; i < 150; i++ {
+			a.AddRecipient(fmt.Sprintf("%d", i), nil)
+		}
+		res, err := a.PostAll(3)
+
+		So(err, ShouldNotEqual, nil)
+		So(strings.Contains(err.Error(), "1 of 3"), ShouldEqual, true)
+		So(IsRetryable(err), ShouldEqual, false)
+		So(calls, ShouldEqual, 3)
+
+		failed := res.Failed()
+		So(len(failed), ShouldEqual, 1)
+		So(failed[0].Index, ShouldEqual, 1)
+		So(len(res.FailedRecipients()), ShouldEqual, 50)
+		So(res.FailedRecipients()[0].ExternalId, ShouldEqual, "50")
+	})
 }
@@ -0,0 +1,248 @@
+package gogo_boy
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+/*
+	----------------------------------------------------------------------
+	Standard user profile attributes
+	----------------------------------------------------------------------
+*/
+
+type Gender string
+
+const (
+	GenderMale           Gender = "M"
+	GenderFemale         Gender = "F"
+	GenderOther          Gender = "O"
+	GenderNotApplicable  Gender = "N"
+	GenderPreferNotToSay Gender = "P"
+)
+
+// Used for both email_subscribe and push_subscribe
+type SubscriptionState string
+
+const (
+	SubscriptionOptedIn      SubscriptionState = "opted_in"
+	SubscriptionSubscribed   SubscriptionState = "subscribed"
+	SubscriptionUnsubscribed SubscriptionState = "unsubscribed"
+)
+
+// Attribute names app-boy treats as part of the profile, anything else set
+// on a TrackRequest is sent as a custom attribute
+var standardAttributes = map[string]bool{
+	"first_name":       true,
+	"last_name":        true,
+	"email":            true,
+	"dob":              true,
+	"gender":           true,
+	"country":          true,
+	"current_location": true,
+	"home_city":        true,
+	"language":         true,
+	"phone":            true,
+	"time_zone":        true,
+	"email_subscribe":  true,
+	"push_subscribe":   true,
+	"image_url":        true,
+	"_merge_objects":   true,
+}
+
+var (
+	countryCodeRegexp  = regexp.MustCompile(`^[A-Z]{2}$`) // ISO-3166-1 alpha-2
+	languageCodeRegexp = regexp.MustCompile(`^[a-z]{2}$`) // ISO-639-1
+)
+
+const dateOfBirthFormat = "2006-01-02"
+
+func (tr *TrackRequest) SetLastName(name string) {
+	tr.Attributes["last_name"] = name
+}
+
+func (tr *TrackRequest) SetDateOfBirth(dob time.Time) {
+	tr.Attributes["dob"] = dob.Format(dateOfBirthFormat)
+}
+
+func (tr *TrackRequest) SetGender(gender Gender) error {
+	if err := validateGender(string(gender)); err != nil {
+		return err
+	}
+
+	tr.Attributes["gender"] = gender
+	return nil
+}
+
+// country is an ISO-3166-1 alpha-2 code like "US"
+func (tr *TrackRequest) SetCountry(country string) error {
+	if !countryCodeRegexp.MatchString(country) {
+		return fmt.Errorf("%q is not an ISO-3166-1 alpha-2 country code", country)
+	}
+
+	tr.Attributes["country"] = country
+	return nil
+}
+
+func (tr *TrackRequest) SetCurrentLocation(latitude, longitude float64) error {
+	location := RawLocation{Latitude: latitude, Longitude: longitude}
+	if err := location.validate(); err != nil {
+		return err
+	}
+
+	tr.Attributes["current_location"] = location
+	return nil
+}
+
+func (tr *TrackRequest) SetHomeCity(city string) {
+	tr.Attributes["home_city"] = city
+}
+
+// language is an ISO-639-1 code like "en"
+func (tr *TrackRequest) SetLanguage(language string) error {
+	if !languageCodeRegexp.MatchString(language) {
+		return fmt.Errorf("%q is not an ISO-639-1 language code", language)
+	}
+
+	tr.Attributes["language"] = language
+	return nil
+}
+
+func (tr *TrackRequest) SetPhone(phone string) {
+	tr.Attributes["phone"] = phone
+}
+
+// timeZone is an IANA name like "America/New_York"
+func (tr *TrackRequest) SetTimeZone(timeZone string) error {
+	if err := validateTimeZone(timeZone); err != nil {
+		return err
+	}
+
+	tr.Attributes["time_zone"] = timeZone
+	return nil
+}
+
+func (tr *TrackRequest) SetEmailSubscribe(state SubscriptionState) error {
+	if err := validateSubscriptionState(string(state)); err != nil {
+		return err
+	}
+
+	tr.Attributes["email_subscribe"] = state
+	return nil
+}
+
+func (tr *TrackRequest) SetPushSubscribe(state SubscriptionState) error {
+	if err := validateSubscriptionState(string(state)); err != nil {
+		return err
+	}
+
+	tr.Attributes["push_subscribe"] = state
+	return nil
+}
+
+func (tr *TrackRequest) SetImageURL(url string) {
+	tr.Attributes["image_url"] = url
+}
+
+// Moves the standard attributes out of attributes (which may have been
+// through a JSON round trip) and onto info, returning the custom ones
+func applyStandardAttributes(info *RawAttributesInfo, attributes map[string]interface{}) (map[string]interface{}, error) {
+	standard := map[string]interface{}{}
+	custom := map[string]interface{}{}
+	for k, v := range attributes {
+		// The raw fields can't express null, custom attributes can
+		if standardAttributes[k] && v != nil {
+			standard[k] = v
+		} else {
+			custom[k] = v
+		}
+	}
+
+	if len(standard) == 0 {
+		return custom, nil
+	}
+
+	_json, err := json.Marshal(standard)
+	if err != nil {
+		return nil, fmt.Errorf("standard attributes can't be marshalled: %s", err)
+	}
+	if err := json.Unmarshal(_json, info); err != nil {
+		return nil, fmt.Errorf("standard attributes have the wrong type: %s", err)
+	}
+
+	return custom, info.validate()
+}
+
+func (info *RawAttributesInfo) validate() error {
+	if info.Gender != "" {
+		if err := validateGender(info.Gender); err != nil {
+			return err
+		}
+	}
+	if info.DateOfBirth != "" {
+		if _, err := time.Parse(dateOfBirthFormat, info.DateOfBirth); err != nil {
+			return fmt.Errorf("dob %q is not formatted as YYYY-MM-DD", info.DateOfBirth)
+		}
+	}
+	if info.Country != "" && !countryCodeRegexp.MatchString(info.Country) {
+		return fmt.Errorf("%q is not an ISO-3166-1 alpha-2 country code", info.Country)
+	}
+	if info.Language != "" && !languageCodeRegexp.MatchString(info.Language) {
+		return fmt.Errorf("%q is not an ISO-639-1 language code", info.Language)
+	}
+	if info.TimeZone != "" {
+		if err := validateTimeZone(info.TimeZone); err != nil {
+			return err
+		}
+	}
+	if info.CurrentLocation != nil {
+		if err := info.CurrentLocation.validate(); err != nil {
+			return err
+		}
+	}
+	for _, state := range []string{info.EmailSubscribe, info.PushSubscribe} {
+		if state != "" {
+			if err := validateSubscriptionState(state); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (l RawLocation) validate() error {
+	if l.Latitude < -90 || l.Latitude > 90 || l.Longitude < -180 || l.Longitude > 180 {
+		return fmt.Errorf("(%f, %f) is not a valid latitude and longitude", l.Latitude, l.Longitude)
+	}
+
+	return nil
+}
+
+func validateGender(gender string) error {
+	switch Gender(gender) {
+	case GenderMale, GenderFemale, GenderOther, GenderNotApplicable, GenderPreferNotToSay:
+		return nil
+	}
+
+	return fmt.Errorf("%q is not a gender app-boy accepts, use one of the Gender constants", gender)
+}
+
+func validateSubscriptionState(state string) error {
+	switch SubscriptionState(state) {
+	case SubscriptionOptedIn, SubscriptionSubscribed, SubscriptionUnsubscribed:
+		return nil
+	}
+
+	return fmt.Errorf("%q is not a subscription state app-boy accepts, use one of the SubscriptionState constants", state)
+}
+
+func validateTimeZone(timeZone string) error {
+	if _, err := time.LoadLocation(timeZone); err != nil || timeZone == "" || timeZone == "Local" {
+		return fmt.Errorf("%q is not an IANA time zone", timeZone)
+	}
+
+	return nil
+}
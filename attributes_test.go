@@ -0,0 +1,110 @@
+package gogo_boy
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAttributes(t *testing.T) {
+	var appClient *AppClient
+	before := func() {
+		appClient = NewClient("foo").NewAppClient("blah")
+	}
+
+	after := func() {
+		StopMocks()
+	}
+
+	Convey("Sends standard attributes as profile fields", t, func() {
+		before()
+		defer after()
+
+		// Mock request to app-boy
+		var request map[string]interface{}
+		MockTrackSuccess(func(_request map[string]interface{}) { request = _request })
+
+		a := appClient.NewTrackRequest("holah")
+		a.SetLastName("bar")
+		a.SetDateOfBirth(time.Date(1990, 2, 3, 0, 0, 0, 0, time.UTC))
+		checkErr(a.SetGender(GenderFemale))
+		checkErr(a.SetCountry("US"))
+		checkErr(a.SetCurrentLocation(40.7, -74))
+		a.SetHomeCity("New York")
+		checkErr(a.SetLanguage("en"))
+		a.SetPhone("+15555555555")
+		checkErr(a.SetTimeZone("America/New_York"))
+		checkErr(a.SetEmailSubscribe(SubscriptionOptedIn))
+		checkErr(a.SetPushSubscribe(SubscriptionUnsubscribed))
+		a.SetImageURL("https://example.com/me.png")
+		_, err := a.Post()
+		checkErr(err)
+
+		attribute := request["attributes"].([]interface{})[0].(map[string]interface{})
+		So(attribute["last_name"], ShouldEqual, "bar")
+		So(attribute["dob"], ShouldEqual, "1990-02-03")
+		So(attribute["gender"], ShouldEqual, "F")
+		So(attribute["country"], ShouldEqual, "US")
+		So(attribute["current_location"], ShouldResemble, map[string]interface{}{"latitude": 40.7, "longitude": -74.0})
+		So(attribute["home_city"], ShouldEqual, "New York")
+		So(attribute["language"], ShouldEqual, "en")
+		So(attribute["phone"], ShouldEqual, "+15555555555")
+		So(attribute["time_zone"], ShouldEqual, "America/New_York")
+		So(attribute["email_subscribe"], ShouldEqual, "opted_in")
+		So(attribute["push_subscribe"], ShouldEqual, "unsubscribed")
+		So(attribute["image_url"], ShouldEqual, "https://example.com/me.png")
+	})
+
+	Convey("Setters reject values app-boy doesn't accept", t, func() {
+		before()
+
+		a := appClient.NewTrackRequest("holah")
+		So(a.SetGender("male"), ShouldNotEqual, nil)
+		So(a.SetCountry("USA"), ShouldNotEqual, nil)
+		So(a.SetCurrentLocation(91, 0), ShouldNotEqual, nil)
+		So(a.SetLanguage("english"), ShouldNotEqual, nil)
+		So(a.SetTimeZone("Mars/Olympus_Mons"), ShouldNotEqual, nil)
+		So(a.SetEmailSubscribe("yes"), ShouldNotEqual, nil)
+		So(a.SetPushSubscribe(""), ShouldNotEqual, nil)
+		So(len(a.Attributes), ShouldEqual, 0)
+	})
+
+	Convey("Standard attributes set by name aren't sent as custom attributes", t, func() {
+		before()
+
+		a := appClient.NewTrackRequest("holah")
+		a.SetCustomValueAttribute("home_city", "Paris")
+		a.SetCustomValueAttribute("favorite_city", "Rome")
+		rt, err := a.rawTrackRequest()
+		checkErr(err)
+
+		So(rt.Attributes[0].HomeCity, ShouldEqual, "Paris")
+		So(rt.Attributes[0].CustomAttributes, ShouldResemble, map[string]interface{}{"favorite_city": "Rome"})
+
+		// Enums are validated even when set by name
+		a.SetCustomValueAttribute("gender", "male")
+		_, err = a.rawTrackRequest()
+		So(err, ShouldNotEqual, nil)
+	})
+
+	Convey("Standard attributes survive a JSON round trip", t, func() {
+		before()
+
+		a := appClient.NewTrackRequest("holah")
+		checkErr(a.SetCurrentLocation(1.5, 2.5))
+		checkErr(a.SetGender(GenderOther))
+
+		res, err := json.Marshal(a)
+		checkErr(err)
+		var b TrackRequest
+		checkErr(json.Unmarshal(res, &b))
+
+		rt, err := b.rawTrackRequest()
+		checkErr(err)
+		So(*rt.Attributes[0].CurrentLocation, ShouldResemble, RawLocation{Latitude: 1.5, Longitude: 2.5})
+		So(rt.Attributes[0].Gender, ShouldEqual, "O")
+		So(len(rt.Attributes[0].CustomAttributes), ShouldEqual, 0)
+	})
+}
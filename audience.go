@@ -0,0 +1,207 @@
+package gogo_boy
+
+import (
+	"fmt"
+	"time"
+)
+
+/*
+	----------------------------------------------------------------------
+	Connected audience filters for campaign and Canvas triggers
+	----------------------------------------------------------------------
+*/
+
+// A connected audience filter, build one with the *Filter functions and
+// combine them with AudienceAnd and AudienceOr
+type Audience map[string]interface{}
+
+type AudienceComparison string
+
+const (
+	// Custom attributes
+	ComparisonEquals               AudienceComparison = "equals"
+	ComparisonNotEqual             AudienceComparison = "not_equal"
+	ComparisonGreaterThan          AudienceComparison = "greater_than"
+	ComparisonGreaterThanOrEqualTo AudienceComparison = "greater_than_or_equal_to"
+	ComparisonLessThan             AudienceComparison = "less_than"
+	ComparisonLessThanOrEqualTo    AudienceComparison = "less_than_or_equal_to"
+	ComparisonMatchesRegex         AudienceComparison = "matches_regex"
+	ComparisonDoesNotMatchRegex    AudienceComparison = "does_not_match_regex"
+	ComparisonIncludesValue        AudienceComparison = "includes_value"
+	ComparisonDoesNotIncludeValue  AudienceComparison = "does_not_include_value"
+	ComparisonExists               AudienceComparison = "exists"
+	ComparisonDoesNotExist         AudienceComparison = "does_not_exist"
+
+	// Custom attributes holding a time, the value is a number of days
+	ComparisonLessThanXDaysAgo         AudienceComparison = "less_than_x_days_ago"
+	ComparisonGreaterThanXDaysAgo      AudienceComparison = "greater_than_x_days_ago"
+	ComparisonLessThanXDaysInFuture    AudienceComparison = "less_than_x_days_in_the_future"
+	ComparisonGreaterThanXDaysInFuture AudienceComparison = "greater_than_x_days_in_the_future"
+
+	// Subscription statuses
+	ComparisonIs    AudienceComparison = "is"
+	ComparisonIsNot AudienceComparison = "is_not"
+
+	// Custom attributes holding a time and last used app
+	ComparisonAfter  AudienceComparison = "after"
+	ComparisonBefore AudienceComparison = "before"
+)
+
+// Which comparisons each kind of filter takes
+var (
+	customAttributeComparisons = map[AudienceComparison]bool{
+		ComparisonEquals: true, ComparisonNotEqual: true,
+		ComparisonGreaterThan: true, ComparisonGreaterThanOrEqualTo: true,
+		ComparisonLessThan: true, ComparisonLessThanOrEqualTo: true,
+		ComparisonMatchesRegex: true, ComparisonDoesNotMatchRegex: true,
+		ComparisonIncludesValue: true, ComparisonDoesNotIncludeValue: true,
+		ComparisonExists: true, ComparisonDoesNotExist: true,
+		ComparisonLessThanXDaysAgo: true, ComparisonGreaterThanXDaysAgo: true,
+		ComparisonLessThanXDaysInFuture: true, ComparisonGreaterThanXDaysInFuture: true,
+		ComparisonAfter: true, ComparisonBefore: true,
+	}
+	subscriptionComparisons = map[AudienceComparison]bool{ComparisonIs: true, ComparisonIsNot: true}
+	lastUsedAppComparisons  = map[AudienceComparison]bool{ComparisonAfter: true, ComparisonBefore: true}
+)
+
+// Users matching every filter
+func AudienceAnd(filters ...Audience) Audience {
+	return Audience{"AND": filters}
+}
+
+// Users matching any of the filters
+func AudienceOr(filters ...Audience) Audience {
+	return Audience{"OR": filters}
+}
+
+// value is ignored by ComparisonExists and ComparisonDoesNotExist, times are
+// sent in ISO 8601
+func CustomAttributeFilter(name string, comparison AudienceComparison, value interface{}) Audience {
+	filter := map[string]interface{}{
+		"custom_attribute_name": name,
+		"comparison":            comparison,
+	}
+	if t, ok := value.(time.Time); ok {
+		value = t.UTC().Format(time.RFC3339)
+	}
+	if comparison != ComparisonExists && comparison != ComparisonDoesNotExist {
+		filter["value"] = value
+	}
+
+	return Audience{"custom_attribute": filter}
+}
+
+func PushSubscriptionFilter(comparison AudienceComparison, state SubscriptionState) Audience {
+	return Audience{"push_subscription_status": map[string]interface{}{
+		"comparison": comparison,
+		"value":      state,
+	}}
+}
+
+func EmailSubscriptionFilter(comparison AudienceComparison, state SubscriptionState) Audience {
+	return Audience{"email_subscription_status": map[string]interface{}{
+		"comparison": comparison,
+		"value":      state,
+	}}
+}
+
+func LastUsedAppFilter(comparison AudienceComparison, t time.Time) Audience {
+	return Audience{"last_used_app": map[string]interface{}{
+		"comparison": comparison,
+		"value":      t.UTC().Format(time.RFC3339),
+	}}
+}
+
+// Checks the filters were built the way app-boy expects, including ones
+// built by hand or read back from JSON
+func (a Audience) validate() error {
+	if len(a) != 1 {
+		return fmt.Errorf("audience filters need exactly one key but this one has %d", len(a))
+	}
+
+	for key, value := range a {
+		switch key {
+		case "AND", "OR":
+			filters := audienceFilters(value)
+			if len(filters) == 0 {
+				return fmt.Errorf("audience %s needs at least one filter", key)
+			}
+			for _, filter := range filters {
+				if err := filter.validate(); err != nil {
+					return err
+				}
+			}
+		case "custom_attribute":
+			filter, _ := value.(map[string]interface{})
+			if audienceString(filter["custom_attribute_name"]) == "" {
+				return fmt.Errorf("custom_attribute audience filters need a custom_attribute_name")
+			}
+			if err := validateComparison(key, filter, customAttributeComparisons); err != nil {
+				return err
+			}
+		case "push_subscription_status", "email_subscription_status":
+			filter, _ := value.(map[string]interface{})
+			if err := validateComparison(key, filter, subscriptionComparisons); err != nil {
+				return err
+			}
+			if err := validateSubscriptionState(audienceString(filter["value"])); err != nil {
+				return fmt.Errorf("%s audience filter: %s", key, err)
+			}
+		case "last_used_app":
+			filter, _ := value.(map[string]interface{})
+			if err := validateComparison(key, filter, lastUsedAppComparisons); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%q is not an audience filter app-boy knows", key)
+		}
+	}
+
+	return nil
+}
+
+func validateComparison(key string, filter map[string]interface{}, allowed map[AudienceComparison]bool) error {
+	comparison := audienceString(filter["comparison"])
+	if !allowed[AudienceComparison(comparison)] {
+		return fmt.Errorf("%q is not a comparison %s audience filters take", comparison, key)
+	}
+
+	return nil
+}
+
+// The filters of an AND or OR, which are plain maps after a JSON round trip
+func audienceFilters(value interface{}) []Audience {
+	switch value := value.(type) {
+	case []Audience:
+		return value
+	case []interface{}:
+		filters := []Audience{}
+		for _, v := range value {
+			switch v := v.(type) {
+			case Audience:
+				filters = append(filters, v)
+			case map[string]interface{}:
+				filters = append(filters, Audience(v))
+			default:
+				filters = append(filters, Audience{})
+			}
+		}
+		return filters
+	}
+
+	return nil
+}
+
+// Typed constants become plain strings after a JSON round trip
+func audienceString(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case AudienceComparison:
+		return string(value)
+	case SubscriptionState:
+		return string(value)
+	}
+
+	return ""
+}
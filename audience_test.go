@@ -0,0 +1,103 @@
+package gogo_boy
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAudience(t *testing.T) {
+	var client *Client
+	before := func() {
+		client = NewClient("foo")
+	}
+
+	after := func() {
+		StopMocks()
+	}
+
+	Convey("Builds connected audience filters", t, func() {
+		lastUsed := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		audience := AudienceAnd(
+			CustomAttributeFilter("plan", ComparisonEquals, "pro"),
+			CustomAttributeFilter("nickname", ComparisonExists, "ignored"),
+			AudienceOr(
+				PushSubscriptionFilter(ComparisonIs, SubscriptionOptedIn),
+				EmailSubscriptionFilter(ComparisonIsNot, SubscriptionUnsubscribed),
+			),
+			LastUsedAppFilter(ComparisonAfter, lastUsed),
+		)
+		checkErr(audience.validate())
+
+		b, err := json.Marshal(audience)
+		checkErr(err)
+		So(string(b), ShouldEqual, `{"AND":[`+
+			`{"custom_attribute":{"comparison":"equals","custom_attribute_name":"plan","value":"pro"}},`+
+			`{"custom_attribute":{"comparison":"exists","custom_attribute_name":"nickname"}},`+
+			`{"OR":[{"push_subscription_status":{"comparison":"is","value":"opted_in"}},{"email_subscription_status":{"comparison":"is_not","value":"unsubscribed"}}]},`+
+			`{"last_used_app":{"comparison":"after","value":"2020-01-02T03:04:05Z"}}]}`)
+
+		// Still valid when read back from JSON
+		var decoded Audience
+		checkErr(json.Unmarshal(b, &decoded))
+		So(decoded.validate(), ShouldEqual, nil)
+	})
+
+	Convey("Rejects audiences app-boy wouldn't take", t, func() {
+		So(CustomAttributeFilter("plan", ComparisonIs, "pro").validate(), ShouldNotEqual, nil)
+		So(CustomAttributeFilter("", ComparisonEquals, "pro").validate(), ShouldNotEqual, nil)
+		So(PushSubscriptionFilter(ComparisonIs, "maybe").validate(), ShouldNotEqual, nil)
+		So(LastUsedAppFilter(ComparisonEquals, time.Now()).validate(), ShouldNotEqual, nil)
+		So(AudienceAnd().validate(), ShouldNotEqual, nil)
+		So(AudienceOr(Audience{"favorite_color": "blue"}).validate(), ShouldNotEqual, nil)
+		So(Audience{}.validate(), ShouldNotEqual, nil)
+	})
+
+	Convey("Can broadcast a campaign to an audience", t, func() {
+		before()
+		defer after()
+
+		// Mock request to app-boy
+		var request map[string]interface{}
+		MockCampaignTriggerSuccess(func(_request map[string]interface{}) { request = _request })
+
+		ctr := client.NewCampaignTriggerRequest("my-campaign-id")
+		ctr.Broadcast = true
+		ctr.SendId = "spring-sale"
+		ctr.TriggerProperties = map[string]interface{}{"discount": "20%"}
+		ctr.Audience = CustomAttributeFilter("plan", ComparisonEquals, "pro")
+		checkErr(ctr.Post())
+		So(request["broadcast"], ShouldEqual, true)
+		So(request["send_id"], ShouldEqual, "spring-sale")
+		So(request["trigger_properties"], ShouldResemble, map[string]interface{}{"discount": "20%"})
+		So(request["audience"], ShouldResemble, map[string]interface{}{
+			"custom_attribute": map[string]interface{}{"custom_attribute_name": "plan", "comparison": "equals", "value": "pro"},
+		})
+		_, ok := request["recipients"]
+		So(ok, ShouldEqual, false)
+	})
+
+	Convey("Rejects campaign triggers that mix or lack recipients and broadcast", t, func() {
+		before()
+
+		ctr := client.NewCampaignTriggerRequest("my-campaign-id")
+		So(ctr.Post(), ShouldNotEqual, nil)
+
+		ctr.Broadcast = true
+		ctr.AddRecipient("holah", nil)
+		So(ctr.Post(), ShouldNotEqual, nil)
+		_, err := ctr.PostAll(1)
+		So(err, ShouldNotEqual, nil)
+
+		ctr = client.NewCampaignTriggerRequest("my-campaign-id")
+		ctr.Broadcast = true
+		ctr.Audience = AudienceOr()
+		So(ctr.Post(), ShouldNotEqual, nil)
+
+		ctr.Audience = nil
+		ctr.SendId = string(make([]byte, MaxSendIdLength+1))
+		So(ctr.Post(), ShouldNotEqual, nil)
+	})
+}
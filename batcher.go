@@ -0,0 +1,296 @@
+package gogo_boy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+/*
+	----------------------------------------------------------------------
+	Packing many users' track requests into one RawTrackRequest
+	----------------------------------------------------------------------
+*/
+
+// App-boy won't take more than this many objects in any one of a track
+// request's attributes, events or purchases arrays
+const MaxTrackObjectsPerArray = 75
+
+var ErrBatcherClosed = errors.New("batcher is closed")
+
+type BatcherConfig struct {
+	// Flush once any array reaches this many objects, defaults to (and is
+	// capped at) MaxTrackObjectsPerArray
+	MaxObjects int
+
+	// Flush whatever is pending this long after the first request was added,
+	// defaults to a second
+	FlushInterval time.Duration
+
+	// How many batches may be posting at once, defaults to 4
+	MaxInFlight int
+}
+
+// What happened to one TrackRequest that went out as part of a batch
+type BatchResult struct {
+	// For the whole batch, the processed counts include other requests
+	Response *TrackResponse
+
+	// The Response errors about this request's objects, with Index pointing
+	// into this request's own Events/PurchaseEvents. Errors that app-boy
+	// didn't tie to an object are included for every request in the batch.
+	Errors []ResponseError
+
+	Err error
+}
+
+// Merges TrackRequests added from any number of goroutines into as few track
+// requests as possible. Push token deletions are merged the same way and sent
+// once the batch's track request went through.
+type Batcher struct {
+	appClient *AppClient
+	config    BatcherConfig
+
+	mu       sync.Mutex
+	pending  *trackBatch
+	timer    *time.Timer
+	closed   bool
+	inFlight chan struct{}
+	wg       sync.WaitGroup
+}
+
+type trackBatch struct {
+	raw     *RawTrackRequest
+	deletes []RawPushTokenInfo
+	entries []*batchEntry
+}
+
+// Where one request's objects ended up inside the batch
+type batchEntry struct {
+	result     chan BatchResult
+	attributes span
+	events     span
+	purchases  span
+	hasDeletes bool
+}
+
+type span struct {
+	start, count int
+}
+
+func (s span) contains(i int) bool {
+	return i >= s.start && i < s.start+s.count
+}
+
+func (c *AppClient) NewBatcher(config BatcherConfig) *Batcher {
+	if config.MaxObjects <= 0 || config.MaxObjects > MaxTrackObjectsPerArray {
+		config.MaxObjects = MaxTrackObjectsPerArray
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Second
+	}
+	if config.MaxInFlight <= 0 {
+		config.MaxInFlight = 4
+	}
+
+	return &Batcher{
+		appClient: c,
+		config:    config,
+		inFlight:  make(chan struct{}, config.MaxInFlight),
+	}
+}
+
+// Queue tr for the next batch. The returned channel receives exactly one
+// result once the batch it went out in was posted.
+func (b *Batcher) Add(tr *TrackRequest) <-chan BatchResult {
+	result := make(chan BatchResult, 1)
+	raw, err := tr.rawTrackRequest()
+	if err != nil {
+		result <- BatchResult{Err: err}
+		return result
+	}
+
+	if l := largestArray(raw); l > b.config.MaxObjects {
+		result <- BatchResult{Err: fmt.Errorf("Batcher can't add a TrackRequest for (%s) with %d objects in one array, the maximum per batch is %d", tr.identifier(), l, b.config.MaxObjects)}
+		return result
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		result <- BatchResult{Err: ErrBatcherClosed}
+		return result
+	}
+
+	if b.pending != nil && !b.pending.fits(raw, b.config.MaxObjects) {
+		b.flushLocked()
+	}
+	if b.pending == nil {
+		b.pending = &trackBatch{
+			raw: &RawTrackRequest{AppGroupId: b.appClient.appGroupId},
+		}
+		batch := b.pending
+		b.timer = time.AfterFunc(b.config.FlushInterval, func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+
+			// Only if that batch hasn't been flushed for being full already
+			if b.pending == batch {
+				b.flushLocked()
+			}
+		})
+	}
+
+	b.pending.add(raw, tr.rawDeletePushTokens(), result)
+	if largestArray(b.pending.raw) >= b.config.MaxObjects {
+		b.flushLocked()
+	}
+
+	return result
+}
+
+// Same as Add but waits for the result, or for ctx to be done. A request
+// that's already queued is still sent if ctx is done.
+func (b *Batcher) Post(ctx context.Context, tr *TrackRequest) (*BatchResult, error) {
+	select {
+	case res := <-b.Add(tr):
+		return &res, res.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Send whatever is pending now instead of waiting for the interval
+func (b *Batcher) Flush() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.flushLocked()
+}
+
+// Flushes and waits for every batch to be posted, later Adds fail with
+// ErrBatcherClosed
+func (b *Batcher) Close() {
+	b.mu.Lock()
+	b.closed = true
+	b.flushLocked()
+	b.mu.Unlock()
+
+	b.wg.Wait()
+}
+
+func (b *Batcher) flushLocked() {
+	if b.pending == nil {
+		return
+	}
+
+	batch := b.pending
+	b.pending = nil
+	b.timer.Stop()
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+
+		b.inFlight <- struct{}{}
+		defer func() { <-b.inFlight }()
+
+		b.send(batch)
+	}()
+}
+
+func (b *Batcher) send(batch *trackBatch) {
+	ctx := context.Background()
+	transport := b.appClient.transport
+
+	res, err := RawPostTrackRequestContext(ctx, transport, batch.raw)
+	if err != nil {
+		for _, entry := range batch.entries {
+			entry.result <- BatchResult{Err: err}
+		}
+		return
+	}
+
+	var deleteErr error
+	if len(batch.deletes) > 0 {
+		deleteErr = RawPostDeletePushTokenRequestContext(ctx, transport, &RawPushTokenDeleteRequest{
+			AppGroupId: batch.raw.AppGroupId,
+			PushTokens: batch.deletes,
+		})
+	}
+
+	for _, entry := range batch.entries {
+		result := BatchResult{
+			Response: res,
+			Errors:   entry.errorsFrom(res),
+		}
+		if entry.hasDeletes {
+			result.Err = deleteErr
+		}
+		entry.result <- result
+	}
+}
+
+func (tb *trackBatch) fits(raw *RawTrackRequest, max int) bool {
+	return len(tb.raw.Attributes)+len(raw.Attributes) <= max &&
+		len(tb.raw.Events)+len(raw.Events) <= max &&
+		len(tb.raw.Purchases)+len(raw.Purchases) <= max
+}
+
+func (tb *trackBatch) add(raw *RawTrackRequest, deletes []RawPushTokenInfo, result chan BatchResult) {
+	entry := &batchEntry{
+		result:     result,
+		attributes: span{len(tb.raw.Attributes), len(raw.Attributes)},
+		events:     span{len(tb.raw.Events), len(raw.Events)},
+		purchases:  span{len(tb.raw.Purchases), len(raw.Purchases)},
+		hasDeletes: len(deletes) > 0,
+	}
+
+	tb.raw.Attributes = append(tb.raw.Attributes, raw.Attributes...)
+	tb.raw.Events = append(tb.raw.Events, raw.Events...)
+	tb.raw.Purchases = append(tb.raw.Purchases, raw.Purchases...)
+	tb.deletes = append(tb.deletes, deletes...)
+	tb.entries = append(tb.entries, entry)
+}
+
+// Picks this entry's errors out of the batch response, re-indexed relative
+// to the entry
+func (e *batchEntry) errorsFrom(res *TrackResponse) []ResponseError {
+	errs := []ResponseError{}
+	for _, re := range res.Errors {
+		var s span
+		switch re.InputArray {
+		case "attributes":
+			s = e.attributes
+		case "events":
+			s = e.events
+		case "purchases":
+			s = e.purchases
+		default:
+			errs = append(errs, re)
+			continue
+		}
+
+		if s.contains(re.Index) {
+			re.Index -= s.start
+			errs = append(errs, re)
+		}
+	}
+
+	return errs
+}
+
+func largestArray(raw *RawTrackRequest) int {
+	l := len(raw.Attributes)
+	if len(raw.Events) > l {
+		l = len(raw.Events)
+	}
+	if len(raw.Purchases) > l {
+		l = len(raw.Purchases)
+	}
+
+	return l
+}
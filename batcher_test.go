@@ -0,0 +1,191 @@
+package gogo_boy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBatcher(t *testing.T) {
+	// Records every request body by path and answers with response
+	type recorder struct {
+		sync.Mutex
+		requests map[string][]map[string]interface{}
+	}
+	record := func(rec *recorder, response string) roundTripperFunc {
+		rec.requests = map[string][]map[string]interface{}{}
+		return func(req *http.Request) (*http.Response, error) {
+			body, _ := ioutil.ReadAll(req.Body)
+			var request map[string]interface{}
+			checkErr(json.Unmarshal(body, &request))
+
+			rec.Lock()
+			rec.requests[req.URL.Path] = append(rec.requests[req.URL.Path], request)
+			rec.Unlock()
+			return newStubResponse(201, response), nil
+		}
+	}
+
+	Convey("Merges requests from many goroutines into one track request", t, func() {
+		var rec recorder
+		appClient := NewClient("foo", WithRoundTripper(record(&rec, `{"attributes_processed":10,"message":"success"}`))).NewAppClient("blah")
+		batcher := appClient.NewBatcher(BatcherConfig{FlushInterval: time.Hour})
+
+		var wg sync.WaitGroup
+		results := make([]BatchResult, 10)
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				tr := appClient.NewTrackRequest(fmt.Sprintf("user-%d", i))
+				tr.SetFirstName("foo")
+				results[i] = <-batcher.Add(tr)
+			}(i)
+		}
+
+		// Let every goroutine add before we close
+		for {
+			batcher.mu.Lock()
+			n := 0
+			if batcher.pending != nil {
+				n = len(batcher.pending.entries)
+			}
+			batcher.mu.Unlock()
+			if n == 10 {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		batcher.Close()
+		wg.Wait()
+
+		So(len(rec.requests[TrackPath]), ShouldEqual, 1)
+		request := rec.requests[TrackPath][0]
+		So(request["app_group_id"], ShouldEqual, "foo")
+		So(len(request["attributes"].([]interface{})), ShouldEqual, 10)
+		for _, result := range results {
+			So(result.Err, ShouldEqual, nil)
+			So(result.Response.AttributesProcessed, ShouldEqual, 10)
+		}
+	})
+
+	Convey("Splits batches at 75 objects per array", t, func() {
+		var rec recorder
+		appClient := NewClient("foo", WithRoundTripper(record(&rec, `{"message":"success"}`))).NewAppClient("blah")
+		batcher := appClient.NewBatcher(BatcherConfig{FlushInterval: time.Hour})
+
+		results := []<-chan BatchResult{}
+		for i := 0; i < 80; i++ {
+			results = append(results, batcher.Add(appClient.NewTrackRequest(fmt.Sprintf("user-%d", i))))
+		}
+		batcher.Close()
+
+		for _, result := range results {
+			So((<-result).Err, ShouldEqual, nil)
+		}
+		So(len(rec.requests[TrackPath]), ShouldEqual, 2)
+		sizes := []int{
+			len(rec.requests[TrackPath][0]["attributes"].([]interface{})),
+			len(rec.requests[TrackPath][1]["attributes"].([]interface{})),
+		}
+		So(sizes, ShouldContain, 75)
+		So(sizes, ShouldContain, 5)
+	})
+
+	Convey("Flushes on the interval", t, func() {
+		var rec recorder
+		appClient := NewClient("foo", WithRoundTripper(record(&rec, `{"message":"success"}`))).NewAppClient("blah")
+		batcher := appClient.NewBatcher(BatcherConfig{FlushInterval: 5 * time.Millisecond})
+		defer batcher.Close()
+
+		var result BatchResult
+		select {
+		case result = <-batcher.Add(appClient.NewTrackRequest("holah")):
+		case <-time.After(time.Second):
+			result.Err = errors.New("the batch was never flushed")
+		}
+		So(result.Err, ShouldEqual, nil)
+	})
+
+	Convey("Reports the errors about each request's own objects", t, func() {
+		var rec recorder
+		response := `{"message":"success","errors":[{"type":"bad event","input_array":"events","index":3},{"type":"something else"}]}`
+		appClient := NewClient("foo", WithRoundTripper(record(&rec, response))).NewAppClient("blah")
+		batcher := appClient.NewBatcher(BatcherConfig{FlushInterval: time.Hour})
+
+		results := []<-chan BatchResult{}
+		for i := 0; i < 2; i++ {
+			tr := appClient.NewTrackRequest(fmt.Sprintf("user-%d", i))
+			for j := 0; j < 2; j++ {
+				event := NewEvent()
+				event.SetName("blah")
+				tr.AddEvent(event)
+			}
+			results = append(results, batcher.Add(tr))
+		}
+		batcher.Flush()
+
+		first := <-results[0]
+		So(len(first.Errors), ShouldEqual, 1)
+		So(first.Errors[0].Type, ShouldEqual, "something else")
+
+		second := <-results[1]
+		So(len(second.Errors), ShouldEqual, 2)
+		So(second.Errors[0].Type, ShouldEqual, "bad event")
+		So(second.Errors[0].Index, ShouldEqual, 1)
+	})
+
+	Convey("Merges push token deletions", t, func() {
+		var rec recorder
+		appClient := NewClient("foo", WithRoundTripper(record(&rec, `{"message":"success"}`))).NewAppClient("blah")
+		batcher := appClient.NewBatcher(BatcherConfig{FlushInterval: time.Hour})
+
+		a := appClient.NewTrackRequest("a")
+		a.RemovePushToken("token-a")
+		b := appClient.NewTrackRequest("b")
+		b.RemovePushToken("token-b")
+		resultA, resultB := batcher.Add(a), batcher.Add(b)
+		batcher.Close()
+
+		So((<-resultA).Err, ShouldEqual, nil)
+		So((<-resultB).Err, ShouldEqual, nil)
+		So(len(rec.requests[DeletePushTokenPath]), ShouldEqual, 1)
+		So(len(rec.requests[DeletePushTokenPath][0]["push_tokens"].([]interface{})), ShouldEqual, 2)
+	})
+
+	Convey("Fails every request of a rejected batch", t, func() {
+		rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return newStubResponse(400, `{"message":"An error message"}`), nil
+		})
+		appClient := NewClient("foo", WithRoundTripper(rt)).NewAppClient("blah")
+		batcher := appClient.NewBatcher(BatcherConfig{})
+
+		resultA := batcher.Add(appClient.NewTrackRequest("a"))
+		resultB := batcher.Add(appClient.NewTrackRequest("b"))
+		batcher.Close()
+
+		So((<-resultA).Err, ShouldNotEqual, nil)
+		So((<-resultB).Err, ShouldNotEqual, nil)
+	})
+
+	Convey("Rejects requests it can't take", t, func() {
+		appClient := NewClient("foo").NewAppClient("blah")
+		batcher := appClient.NewBatcher(BatcherConfig{MaxObjects: 2})
+
+		tr := appClient.NewTrackRequest("holah")
+		for i := 0; i < 3; i++ {
+			tr.AddEvent(NewEvent())
+		}
+		So((<-batcher.Add(tr)).Err, ShouldNotEqual, nil)
+
+		batcher.Close()
+		So((<-batcher.Add(appClient.NewTrackRequest("holah"))).Err, ShouldEqual, ErrBatcherClosed)
+	})
+}
@@ -0,0 +1,232 @@
+package gogo_boy
+
+import (
+	"context"
+	"fmt"
+)
+
+/*
+	----------------------------------------------------------------------
+	Raw requests for API triggered Canvases
+	----------------------------------------------------------------------
+*/
+
+const (
+	CanvasTriggerPath = "/canvas/trigger/send"
+
+	// Full URL on the default host
+	CanvasTriggerEndpoint = DefaultBaseURL + CanvasTriggerPath
+)
+
+// App-boy won't trigger a Canvas for more recipients than this per request
+const MaxCanvasTriggerRecipients = 50
+
+// Enter users into a Canvas. Either list Recipients or set Broadcast to send
+// to everyone in the Canvas's segment (narrowed down by Audience if set).
+type RawCanvasTriggerRequest struct {
+	AppGroupId            string                 `json:"app_group_id,omitempty"`
+	CanvasId              string                 `json:"canvas_id"`
+	CanvasEntryProperties map[string]interface{} `json:"canvas_entry_properties,omitempty"` // For every recipient
+	Broadcast             bool                   `json:"broadcast,omitempty"`
+	Audience              Audience               `json:"audience,omitempty"`
+	Recipients            []RawCanvasRecipient   `json:"recipients,omitempty"`
+}
+
+// Set one of ExternalId, UserAlias or Email
+type RawCanvasRecipient struct {
+	ExternalId            string                 `json:"external_user_id,omitempty"`
+	UserAlias             *UserAlias             `json:"user_alias,omitempty"`
+	Email                 string                 `json:"email,omitempty"`
+	CanvasEntryProperties map[string]interface{} `json:"canvas_entry_properties,omitempty"`
+}
+
+type CanvasTriggerResponse struct {
+	DispatchId string `json:"dispatch_id"`
+	Message    string `json:"message"`
+}
+
+func RawPostCanvasTriggerRequest(rawReq *RawCanvasTriggerRequest) (*CanvasTriggerResponse, error) {
+	return RawPostCanvasTriggerRequestContext(context.Background(), nil, rawReq)
+}
+
+// Same as RawPostCanvasTriggerRequest but sent over transport (nil for the
+// defaults) and aborts when ctx is done
+func RawPostCanvasTriggerRequestContext(ctx context.Context, transport *Transport, rawReq *RawCanvasTriggerRequest) (*CanvasTriggerResponse, error) {
+	res := &CanvasTriggerResponse{}
+	if err := transportOrDefault(transport).postJSON(ctx, "RawCanvasTriggerRequest", CanvasTriggerPath, rawReq, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+/*
+	----------------------------------------------------------------------
+	Prettier version, works like CampaignTriggerRequest
+	----------------------------------------------------------------------
+*/
+
+type CanvasTriggerRequest struct {
+	AppGroupId string
+	CanvasId   string
+
+	CanvasEntryProperties map[string]interface{}
+	Broadcast             bool
+	Audience              Audience
+
+	Recipients []RawCanvasRecipient
+
+	transport *Transport
+}
+
+func (c *Client) NewCanvasTriggerRequest(canvasId string) *CanvasTriggerRequest {
+	return &CanvasTriggerRequest{
+		AppGroupId: c.appGroupId,
+		CanvasId:   canvasId,
+		Recipients: []RawCanvasRecipient{},
+		transport:  c.transport,
+	}
+}
+
+func (ctr *CanvasTriggerRequest) AddRecipient(externalId string, canvasEntryProperties map[string]interface{}) {
+	ctr.Recipients = append(ctr.Recipients, RawCanvasRecipient{
+		ExternalId:            externalId,
+		CanvasEntryProperties: canvasEntryProperties,
+	})
+}
+
+// Canvases can't be triggered by braze_id
+func (ctr *CanvasTriggerRequest) AddRecipientFor(id Identifier, canvasEntryProperties map[string]interface{}) error {
+	if err := id.validate(); err != nil {
+		return err
+	}
+	if id.BrazeId != "" {
+		return fmt.Errorf("canvas recipients can't be identified by braze_id")
+	}
+
+	ctr.Recipients = append(ctr.Recipients, RawCanvasRecipient{
+		ExternalId:            id.ExternalId,
+		UserAlias:             id.UserAlias,
+		Email:                 id.Email,
+		CanvasEntryProperties: canvasEntryProperties,
+	})
+	return nil
+}
+
+func (ctr *CanvasTriggerRequest) Post() (*CanvasTriggerResponse, error) {
+	return ctr.PostContext(context.Background())
+}
+
+// Same as Post but aborts when ctx is done
+func (ctr *CanvasTriggerRequest) PostContext(ctx context.Context) (*CanvasTriggerResponse, error) {
+	if err := ctr.validate(); err != nil {
+		return nil, err
+	}
+
+	if lr := len(ctr.Recipients); lr > MaxCanvasTriggerRecipients {
+		return nil, fmt.Errorf("Tried to post a CanvasTriggerRequest for the [AppBoyCanvas](canvas_id: %s) but there were %d recipients which exceeds the maximum of %d per request.  You will need to break your canvas trigger requests up into multiple requests (or use PostAll) in order to send more than %d recipients", ctr.CanvasId, lr, MaxCanvasTriggerRecipients, MaxCanvasTriggerRecipients)
+	}
+
+	return RawPostCanvasTriggerRequestContext(ctx, ctr.transport, ctr.rawCanvasTriggerRequest(ctr.Recipients))
+}
+
+func (ctr *CanvasTriggerRequest) validate() error {
+	if ctr.Broadcast && len(ctr.Recipients) > 0 {
+		return fmt.Errorf("CanvasTriggerRequest for the [AppBoyCanvas](canvas_id: %s) can't broadcast and list recipients at the same time", ctr.CanvasId)
+	}
+	if !ctr.Broadcast && len(ctr.Recipients) == 0 {
+		return fmt.Errorf("CanvasTriggerRequest for the [AppBoyCanvas](canvas_id: %s) has no recipients, set Broadcast to send to the whole audience", ctr.CanvasId)
+	}
+	if ctr.Audience != nil {
+		if err := ctr.Audience.validate(); err != nil {
+			return fmt.Errorf("CanvasTriggerRequest for the [AppBoyCanvas](canvas_id: %s) has an invalid audience: %s", ctr.CanvasId, err)
+		}
+	}
+
+	return nil
+}
+
+func (ctr *CanvasTriggerRequest) rawCanvasTriggerRequest(recipients []RawCanvasRecipient) *RawCanvasTriggerRequest {
+	return &RawCanvasTriggerRequest{
+		AppGroupId:            ctr.AppGroupId,
+		CanvasId:              ctr.CanvasId,
+		CanvasEntryProperties: ctr.CanvasEntryProperties,
+		Broadcast:             ctr.Broadcast,
+		Audience:              ctr.Audience,
+		Recipients:            recipients,
+	}
+}
+
+// One request's worth of recipients sent by PostAll
+type CanvasTriggerChunk struct {
+	Index      int
+	Recipients []RawCanvasRecipient
+	Response   *CanvasTriggerResponse
+	Err        error
+}
+
+type CanvasTriggerResult struct {
+	Chunks []CanvasTriggerChunk
+}
+
+func (r *CanvasTriggerResult) Failed() []CanvasTriggerChunk {
+	failed := []CanvasTriggerChunk{}
+	for _, chunk := range r.Chunks {
+		if chunk.Err != nil {
+			failed = append(failed, chunk)
+		}
+	}
+
+	return failed
+}
+
+// Everyone who may not have entered the Canvas, handy for trying again
+func (r *CanvasTriggerResult) FailedRecipients() []RawCanvasRecipient {
+	recipients := []RawCanvasRecipient{}
+	for _, chunk := range r.Failed() {
+		recipients = append(recipients, chunk.Recipients...)
+	}
+
+	return recipients
+}
+
+// Same as CampaignTriggerRequest's PostAll, broadcasts can't be split up so
+// they go through Post
+func (ctr *CanvasTriggerRequest) PostAll(concurrency int) (*CanvasTriggerResult, error) {
+	return ctr.PostAllContext(context.Background(), concurrency)
+}
+
+// Same as PostAll but aborts when ctx is done, chunks that weren't sent yet
+// fail with the context's error
+func (ctr *CanvasTriggerRequest) PostAllContext(ctx context.Context, concurrency int) (*CanvasTriggerResult, error) {
+	if ctr.Broadcast {
+		return nil, fmt.Errorf("PostAll for the [AppBoyCanvas](canvas_id: %s) can't split up a broadcast, use Post", ctr.CanvasId)
+	}
+	if err := ctr.validate(); err != nil {
+		return nil, err
+	}
+
+	ranges := chunkRanges(len(ctr.Recipients), MaxCanvasTriggerRecipients)
+	res := &CanvasTriggerResult{}
+	for i, r := range ranges {
+		res.Chunks = append(res.Chunks, CanvasTriggerChunk{
+			Index:      i,
+			Recipients: ctr.Recipients[r[0]:r[1]],
+		})
+	}
+
+	errs := postChunks(ctx, len(res.Chunks), concurrency, func(ctx context.Context, i int) error {
+		var err error
+		res.Chunks[i].Response, err = RawPostCanvasTriggerRequestContext(ctx, ctr.transport, ctr.rawCanvasTriggerRequest(res.Chunks[i].Recipients))
+		return err
+	})
+	for i, err := range errs {
+		res.Chunks[i].Err = err
+	}
+
+	if failed := res.Failed(); len(failed) > 0 {
+		return res, fmt.Errorf("PostAll for the [AppBoyCanvas](canvas_id: %s) failed for %d of %d chunks (%d recipients), the first error was: %w", ctr.CanvasId, len(failed), len(res.Chunks), len(res.FailedRecipients()), failed[0].Err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,144 @@
+package gogo_boy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCanvasAPI(t *testing.T) {
+	var client *Client
+	before := func() {
+		client = NewClient("foo")
+	}
+
+	after := func() {
+		StopMocks()
+	}
+
+	Convey("Can trigger a canvas for recipients", t, func() {
+		before()
+		defer after()
+
+		// Mock request to app-boy
+		var request map[string]interface{}
+		MockCanvasTriggerSuccess(func(_request map[string]interface{}) { request = _request })
+
+		ctr := client.NewCanvasTriggerRequest("my-canvas-id")
+		ctr.CanvasEntryProperties = map[string]interface{}{"source": "api"}
+		ctr.AddRecipient("holah", map[string]interface{}{"plan": "pro"})
+		checkErr(ctr.AddRecipientFor(ByUserAlias("anon-123", "device"), nil))
+		So(ctr.AddRecipientFor(ByBrazeId("5cd1"), nil), ShouldNotEqual, nil)
+
+		res, err := ctr.Post()
+		checkErr(err)
+		So(res.DispatchId, ShouldEqual, "dispatch-1")
+		So(request["canvas_id"], ShouldEqual, "my-canvas-id")
+		So(request["app_group_id"], ShouldEqual, "foo")
+		So(request["canvas_entry_properties"], ShouldResemble, map[string]interface{}{"source": "api"})
+		So(request["recipients"], ShouldResemble, []interface{}{
+			map[string]interface{}{"external_user_id": "holah", "canvas_entry_properties": map[string]interface{}{"plan": "pro"}},
+			map[string]interface{}{"user_alias": map[string]interface{}{"alias_name": "anon-123", "alias_label": "device"}},
+		})
+		_, ok := request["broadcast"]
+		So(ok, ShouldEqual, false)
+	})
+
+	Convey("Can broadcast a canvas to an audience", t, func() {
+		before()
+		defer after()
+
+		// Mock request to app-boy
+		var request map[string]interface{}
+		MockCanvasTriggerSuccess(func(_request map[string]interface{}) { request = _request })
+
+		ctr := client.NewCanvasTriggerRequest("my-canvas-id")
+		ctr.Broadcast = true
+		ctr.Audience = Audience{"custom_attribute": map[string]interface{}{"custom_attribute_name": "plan", "comparison": "equals", "value": "pro"}}
+		_, err := ctr.Post()
+		checkErr(err)
+		So(request["broadcast"], ShouldEqual, true)
+		So(request["audience"], ShouldNotBeNil)
+		_, ok := request["recipients"]
+		So(ok, ShouldEqual, false)
+	})
+
+	Convey("Rejects requests that mix or lack recipients and broadcast", t, func() {
+		before()
+
+		ctr := client.NewCanvasTriggerRequest("my-canvas-id")
+		_, err := ctr.Post()
+		So(err, ShouldNotEqual, nil)
+
+		ctr.Broadcast = true
+		ctr.AddRecipient("holah", nil)
+		_, err = ctr.Post()
+		So(err, ShouldNotEqual, nil)
+		_, err = ctr.PostAll(2)
+		So(err, ShouldNotEqual, nil)
+	})
+
+	Convey("Returns app-boy's errors", t, func() {
+		before()
+		defer after()
+
+		MockCanvasTriggerFailure(func(map[string]interface{}) {})
+
+		ctr := client.NewCanvasTriggerRequest("bad-canvas-id")
+		ctr.AddRecipient("holah", nil)
+		_, err := ctr.Post()
+		var apiErr *APIError
+		So(errors.As(err, &apiErr), ShouldEqual, true)
+		So(apiErr.Message, ShouldEqual, "Invalid canvas_id")
+	})
+
+	Convey("Won't send more than 50 recipients in one request but PostAll splits them", t, func() {
+		var mu sync.Mutex
+		var calls int
+		client := NewClient("foo", WithRoundTripper(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			calls++
+			mu.Unlock()
+			return newStubResponse(201, `{"dispatch_id":"dispatch-1","message":"success"}`), nil
+		})))
+
+		ctr := client.NewCanvasTriggerRequest("my-canvas-id")
+		for i := 0; i < 120; i++ {
+			ctr.AddRecipient(fmt.Sprint(i), nil)
+		}
+
+		_, err := ctr.Post()
+		So(err, ShouldNotEqual, nil)
+		So(calls, ShouldEqual, 0)
+
+		res, err := ctr.PostAllContext(context.Background(), 2)
+		checkErr(err)
+		So(calls, ShouldEqual, 3)
+		So(len(res.Chunks), ShouldEqual, 3)
+		So(len(res.Chunks[2].Recipients), ShouldEqual, 20)
+		So(res.Chunks[2].Response.DispatchId, ShouldEqual, "dispatch-1")
+		So(len(res.FailedRecipients()), ShouldEqual, 0)
+	})
+
+	Convey("Canvas triggers aren't retried after network errors", t, func() {
+		var calls int
+		client := NewClient("foo",
+			WithRetryPolicy(DefaultRetryPolicy()),
+			WithRoundTripper(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				calls++
+				return nil, errors.New("connection reset by peer")
+			})),
+		)
+
+		ctr := client.NewCanvasTriggerRequest("my-canvas-id")
+		ctr.AddRecipient("holah", nil)
+		_, err := ctr.Post()
+		So(err, ShouldNotEqual, nil)
+		So(calls, ShouldEqual, 1)
+	})
+}
@@ -0,0 +1,97 @@
+package gogo_boy
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCurrency(t *testing.T) {
+	var appClient *AppClient
+	before := func() {
+		appClient = NewClient("foo").NewAppClient("blah")
+	}
+
+	after := func() {
+		StopMocks()
+	}
+
+	Convey("Sends prices exactly as set", t, func() {
+		before()
+		defer after()
+
+		// Mock the raw body to check how the price is written
+		var body []byte
+		client := NewClient("foo", WithRoundTripper(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			body, _ = ioutil.ReadAll(req.Body)
+			return newStubResponse(201, `{"message":"success"}`), nil
+		})))
+
+		a := client.NewAppClient("blah").NewTrackRequest("holah")
+		purchase := NewPurchaseEvent()
+		purchase.SetProductId("bar")
+		checkErr(purchase.SetCurrency("EUR"))
+		checkErr(purchase.SetPriceDecimal("12345678901234567.89"))
+		a.AddEvent(purchase)
+
+		_, err := a.Post()
+		checkErr(err)
+		So(string(body), ShouldContainSubstring, `"price":12345678901234567.89,`)
+		So(string(body), ShouldNotContainSubstring, `"quantity"`)
+	})
+
+	Convey("Converts minor units using the currency's decimal places", t, func() {
+		purchase := NewPurchaseEvent()
+		So(purchase.SetPriceMinorUnits(492), ShouldNotEqual, nil)
+
+		checkErr(purchase.SetCurrency("USD"))
+		checkErr(purchase.SetPriceMinorUnits(492))
+		So(purchase.Price, ShouldEqual, json.Number("4.92"))
+		checkErr(purchase.SetPriceMinorUnits(5))
+		So(purchase.Price, ShouldEqual, json.Number("0.05"))
+		checkErr(purchase.SetPriceMinorUnits(-105))
+		So(purchase.Price, ShouldEqual, json.Number("-1.05"))
+
+		checkErr(purchase.SetCurrency("JPY"))
+		checkErr(purchase.SetPriceMinorUnits(492))
+		So(purchase.Price, ShouldEqual, json.Number("492"))
+
+		checkErr(purchase.SetCurrency("KWD"))
+		checkErr(purchase.SetPriceMinorUnits(4920))
+		So(purchase.Price, ShouldEqual, json.Number("4.920"))
+	})
+
+	Convey("Rejects currencies, prices and quantities app-boy doesn't accept", t, func() {
+		before()
+
+		purchase := NewPurchaseEvent()
+		So(purchase.SetCurrency("usd"), ShouldNotEqual, nil)
+		So(purchase.SetCurrency("XYZ"), ShouldNotEqual, nil)
+		So(purchase.SetPriceDecimal("4,92"), ShouldNotEqual, nil)
+		So(purchase.SetPriceDecimal("1e3"), ShouldNotEqual, nil)
+		So(purchase.SetQuantityChecked(0), ShouldNotEqual, nil)
+		So(purchase.SetQuantityChecked(101), ShouldNotEqual, nil)
+		So(purchase.Quantity, ShouldEqual, 0)
+		So(purchase.SetQuantityChecked(100), ShouldEqual, nil)
+		So(purchase.Quantity, ShouldEqual, 100)
+
+		// Checked again when posting since the fields can be set directly
+		post := func(currency, price string) error {
+			a := appClient.NewTrackRequest("holah")
+			purchase := NewPurchaseEvent()
+			purchase.Currency = currency
+			purchase.Price = json.Number(price)
+			a.AddEvent(purchase)
+			_, err := a.rawTrackRequest()
+			return err
+		}
+		So(post("", "1"), ShouldNotEqual, nil)
+		So(post("JPY", "4.92"), ShouldNotEqual, nil)
+		So(post("USD", "4.925"), ShouldNotEqual, nil)
+		So(post("USD", "4.920"), ShouldEqual, nil)
+		So(post("JPY", "492"), ShouldEqual, nil)
+	})
+}
@@ -0,0 +1,168 @@
+package gogo_boy
+
+import (
+	"fmt"
+)
+
+/*
+	----------------------------------------------------------------------
+	Custom attribute operations beyond setting a value
+	----------------------------------------------------------------------
+*/
+
+// Keys of the objects app-boy reads as an operation on a custom attribute
+// rather than as the attribute's new value, mapped to the kind of attribute
+// they work on. App-boy only takes one kind of operation per attribute.
+var customAttributeOps = map[string]string{
+	"inc":     "integer",
+	"add":     "array",
+	"remove":  "array",
+	"$add":    "array of objects",
+	"$update": "array of objects",
+	"$remove": "array of objects",
+}
+
+// Add n (which may be negative) to an integer custom attribute, repeated
+// calls add up. Sending the request twice adds n twice, so like events and
+// purchases it keeps the whole request from being retried (see
+// RetryPolicy.RetryNonIdempotent).
+func (tr *TrackRequest) IncrementCustomAttribute(name string, n int) error {
+	op, err := tr.customAttributeOp(name, "inc")
+	if err != nil {
+		return err
+	}
+
+	op["inc"] = toInt(op["inc"]) + n
+	return nil
+}
+
+// Add values to an array custom attribute
+func (tr *TrackRequest) AddToCustomAttributeArray(name string, values ...interface{}) error {
+	op, err := tr.customAttributeOp(name, "add")
+	if err != nil {
+		return err
+	}
+
+	op["add"] = appendValues(op["add"], values...)
+	return nil
+}
+
+// Remove values from an array custom attribute
+func (tr *TrackRequest) RemoveFromCustomAttributeArray(name string, values ...interface{}) error {
+	op, err := tr.customAttributeOp(name, "remove")
+	if err != nil {
+		return err
+	}
+
+	op["remove"] = appendValues(op["remove"], values...)
+	return nil
+}
+
+// Replace a nested object custom attribute with obj
+func (tr *TrackRequest) SetCustomObjectAttribute(name string, obj map[string]interface{}) {
+	tr.Attributes[name] = obj
+}
+
+// Merge obj into a nested object custom attribute, keys not in obj are left
+// alone. This turns on _merge_objects which applies to every nested object
+// custom attribute of this request.
+func (tr *TrackRequest) UpdateCustomObjectAttribute(name string, obj map[string]interface{}) {
+	tr.Attributes[name] = obj
+	tr.Attributes["_merge_objects"] = true
+}
+
+// Append objects to an array of objects custom attribute
+func (tr *TrackRequest) AddCustomObjectsToArray(name string, objs ...map[string]interface{}) error {
+	op, err := tr.customAttributeOp(name, "$add")
+	if err != nil {
+		return err
+	}
+
+	for _, obj := range objs {
+		op["$add"] = appendValues(op["$add"], obj)
+	}
+	return nil
+}
+
+// Merge newObject into the objects of an array of objects custom attribute
+// whose identifierKey is identifierValue
+func (tr *TrackRequest) UpdateCustomObjectInArray(name, identifierKey string, identifierValue interface{}, newObject map[string]interface{}) error {
+	op, err := tr.customAttributeOp(name, "$update")
+	if err != nil {
+		return err
+	}
+
+	op["$update"] = appendValues(op["$update"], map[string]interface{}{
+		"$identifier_key":   identifierKey,
+		"$identifier_value": identifierValue,
+		"$new_object":       newObject,
+	})
+	return nil
+}
+
+// Remove the objects of an array of objects custom attribute whose
+// identifierKey is identifierValue
+func (tr *TrackRequest) RemoveCustomObjectFromArray(name, identifierKey string, identifierValue interface{}) error {
+	op, err := tr.customAttributeOp(name, "$remove")
+	if err != nil {
+		return err
+	}
+
+	op["$remove"] = appendValues(op["$remove"], map[string]interface{}{
+		"$identifier_key":   identifierKey,
+		"$identifier_value": identifierValue,
+	})
+	return nil
+}
+
+// Sends null for the attribute which removes it from the profile, works for
+// standard attributes too
+func (tr *TrackRequest) UnsetAttribute(name string) {
+	tr.Attributes[name] = nil
+}
+
+// The pending operations on name that key can join, anything that isn't
+// already an operation (e.g. a plain value that was set earlier) is replaced.
+// Pending operations of another kind are an error since app-boy wouldn't know
+// what the attribute is.
+func (tr *TrackRequest) customAttributeOp(name, key string) (map[string]interface{}, error) {
+	if op, ok := tr.Attributes[name].(map[string]interface{}); ok && len(op) > 0 {
+		kind := ""
+		for k := range op {
+			if customAttributeOps[k] == "" {
+				kind = ""
+				break
+			}
+			kind = customAttributeOps[k]
+		}
+
+		if kind != "" {
+			if kind != customAttributeOps[key] {
+				return nil, fmt.Errorf("the custom attribute %q already has %s operations, %q can't be mixed in", name, kind, key)
+			}
+			return op, nil
+		}
+	}
+
+	op := map[string]interface{}{}
+	tr.Attributes[name] = op
+	return op, nil
+}
+
+// Values that went through a JSON round trip come back as []interface{}
+// and float64, these put them back together either way
+func appendValues(existing interface{}, values ...interface{}) []interface{} {
+	list, _ := existing.([]interface{})
+	return append(list, values...)
+}
+
+func toInt(v interface{}) int {
+	switch v := v.(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	}
+
+	return 0
+}
@@ -0,0 +1,129 @@
+package gogo_boy
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCustomAttributes(t *testing.T) {
+	var appClient *AppClient
+	before := func() {
+		appClient = NewClient("foo").NewAppClient("blah")
+	}
+
+	after := func() {
+		StopMocks()
+	}
+
+	Convey("Sends custom attribute operations", t, func() {
+		before()
+		defer after()
+
+		// Mock request to app-boy
+		var request map[string]interface{}
+		MockTrackSuccess(func(_request map[string]interface{}) { request = _request })
+
+		a := appClient.NewTrackRequest("holah")
+		checkErr(a.IncrementCustomAttribute("logins", 2))
+		checkErr(a.IncrementCustomAttribute("logins", 1))
+		checkErr(a.AddToCustomAttributeArray("teams", "red", "blue"))
+		checkErr(a.RemoveFromCustomAttributeArray("teams", "green"))
+		checkErr(a.AddCustomObjectsToArray("pets", map[string]interface{}{"id": 1, "name": "rex"}))
+		checkErr(a.UpdateCustomObjectInArray("pets", "id", 2, map[string]interface{}{"name": "tom"}))
+		checkErr(a.RemoveCustomObjectFromArray("pets", "id", 3))
+		a.UnsetAttribute("nickname")
+		a.UnsetAttribute("home_city")
+		_, err := a.Post()
+		checkErr(err)
+
+		attribute := request["attributes"].([]interface{})[0].(map[string]interface{})
+		So(attribute["logins"], ShouldResemble, map[string]interface{}{"inc": 3.0})
+		So(attribute["teams"], ShouldResemble, map[string]interface{}{
+			"add":    []interface{}{"red", "blue"},
+			"remove": []interface{}{"green"},
+		})
+		So(attribute["pets"], ShouldResemble, map[string]interface{}{
+			"$add": []interface{}{map[string]interface{}{"id": 1.0, "name": "rex"}},
+			"$update": []interface{}{map[string]interface{}{
+				"$identifier_key":   "id",
+				"$identifier_value": 2.0,
+				"$new_object":       map[string]interface{}{"name": "tom"},
+			}},
+			"$remove": []interface{}{map[string]interface{}{
+				"$identifier_key":   "id",
+				"$identifier_value": 3.0,
+			}},
+		})
+
+		// Explicit nulls, even for standard attributes
+		value, ok := attribute["nickname"]
+		So(ok, ShouldEqual, true)
+		So(value, ShouldBeNil)
+		value, ok = attribute["home_city"]
+		So(ok, ShouldEqual, true)
+		So(value, ShouldBeNil)
+		So(attribute["_merge_objects"], ShouldBeNil)
+	})
+
+	Convey("Nested objects are replaced or merged", t, func() {
+		before()
+
+		a := appClient.NewTrackRequest("holah")
+		a.SetCustomObjectAttribute("car", map[string]interface{}{"make": "honda", "year": 2010})
+		rt, err := a.rawTrackRequest()
+		checkErr(err)
+		So(rt.Attributes[0].MergeObjects, ShouldEqual, false)
+
+		a.UpdateCustomObjectAttribute("car", map[string]interface{}{"year": 2012})
+		rt, err = a.rawTrackRequest()
+		checkErr(err)
+		So(rt.Attributes[0].MergeObjects, ShouldEqual, true)
+		So(rt.Attributes[0].CustomAttributes, ShouldResemble, map[string]interface{}{
+			"car": map[string]interface{}{"year": 2012},
+		})
+
+		res, err := marshalTrackRequest(rt)
+		checkErr(err)
+		So(string(res), ShouldContainSubstring, `"_merge_objects":true`)
+		So(string(res), ShouldContainSubstring, `"car":{"year":2012}`)
+	})
+
+	Convey("Operations replace a plain value and survive a JSON round trip", t, func() {
+		before()
+
+		a := appClient.NewTrackRequest("holah")
+		a.SetCustomValueAttribute("logins", 5)
+		checkErr(a.IncrementCustomAttribute("logins", 1))
+		checkErr(a.AddToCustomAttributeArray("teams", "red"))
+
+		res, err := json.Marshal(a)
+		checkErr(err)
+		var b TrackRequest
+		checkErr(json.Unmarshal(res, &b))
+
+		checkErr(b.IncrementCustomAttribute("logins", 1))
+		checkErr(b.AddToCustomAttributeArray("teams", "blue"))
+		So(b.Attributes["logins"], ShouldResemble, map[string]interface{}{"inc": 2})
+		So(b.Attributes["teams"], ShouldResemble, map[string]interface{}{"add": []interface{}{"red", "blue"}})
+	})
+	Convey("Refuses to mix kinds of operations on one attribute", t, func() {
+		before()
+
+		a := appClient.NewTrackRequest("holah")
+		checkErr(a.IncrementCustomAttribute("logins", 1))
+		So(a.AddToCustomAttributeArray("logins", "red"), ShouldNotEqual, nil)
+		So(a.RemoveCustomObjectFromArray("logins", "id", 1), ShouldNotEqual, nil)
+		So(a.Attributes["logins"], ShouldResemble, map[string]interface{}{"inc": 1})
+
+		checkErr(a.AddToCustomAttributeArray("teams", "red"))
+		So(a.IncrementCustomAttribute("teams", 1), ShouldNotEqual, nil)
+		So(a.AddCustomObjectsToArray("teams", map[string]interface{}{"id": 1}), ShouldNotEqual, nil)
+		checkErr(a.RemoveFromCustomAttributeArray("teams", "blue"))
+
+		// Setting a value starts over
+		a.SetCustomValueAttribute("teams", []string{"green"})
+		checkErr(a.IncrementCustomAttribute("teams", 1))
+	})
+}
@@ -0,0 +1,123 @@
+package gogo_boy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+/*
+	----------------------------------------------------------------------
+	Errors returned when app-boy rejects a request
+	----------------------------------------------------------------------
+*/
+
+// Returned whenever app-boy answers with an unexpected status code. Use
+// errors.As (or the Is* helpers below) to get at it through any wrapping.
+type APIError struct {
+	Op         string // The function that failed, e.g. PostTrackRequest
+	Method     string // GET or POST
+	Endpoint   string // Full URL that was requested
+	StatusCode int
+
+	// Parsed from the response body when it's JSON
+	Message string
+	Errors  []ResponseError
+
+	Body   []byte // Raw response body
+	Header http.Header
+}
+
+// App-boy reports problems with individual objects of a request (on failure
+// and on partial success) as an "errors" array. Entries are usually objects
+// but older endpoints send plain strings, which end up in Type.
+type ResponseError struct {
+	Type       string `json:"type"`
+	InputArray string `json:"input_array,omitempty"` // e.g. attributes, events, purchases
+	Index      int    `json:"index"`                 // Position in InputArray
+}
+
+func (e *ResponseError) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		*e = ResponseError{Type: str}
+		return nil
+	}
+
+	// Alias drops our UnmarshalJSON so we don't recurse
+	type responseError ResponseError
+	var re responseError
+	if err := json.Unmarshal(data, &re); err != nil {
+		return err
+	}
+	*e = ResponseError(re)
+	return nil
+}
+
+func newAPIError(op, method, endpoint string, statusCode int, header http.Header, body []byte) *APIError {
+	e := &APIError{
+		Op:         op,
+		Method:     method,
+		Endpoint:   endpoint,
+		StatusCode: statusCode,
+		Body:       body,
+		Header:     header,
+	}
+
+	// Best effort, plenty of failures (e.g. 5xx from a load balancer) aren't JSON
+	var payload struct {
+		Message string          `json:"message"`
+		Errors  []ResponseError `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil {
+		e.Message = payload.Message
+		e.Errors = payload.Errors
+	}
+
+	return e
+}
+
+func (e *APIError) Error() string {
+	path := e.Endpoint
+	if u, err := url.Parse(e.Endpoint); err == nil {
+		path = u.Path
+	}
+
+	return fmt.Sprintf("%s failed: app-boy answered %s %s with a %d and the payload: '%s'", e.Op, e.Method, path, e.StatusCode, e.Body)
+}
+
+func (e *APIError) IsRateLimited() bool {
+	return e.StatusCode == 429
+}
+
+// Rate limits and server side failures are worth trying again, anything else
+// will fail the same way next time
+func (e *APIError) IsRetryable() bool {
+	return e.IsRateLimited() || e.StatusCode >= 500
+}
+
+func (e *APIError) IsAuthError() bool {
+	return e.StatusCode == 401 || e.StatusCode == 403
+}
+
+// True for 429s from app-boy and for our own client side limiter
+func IsRateLimited(err error) bool {
+	if errors.Is(err, ErrRateLimitExceeded) {
+		return true
+	}
+
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.IsRateLimited()
+}
+
+func IsRetryable(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.IsRetryable()
+}
+
+func IsAuthError(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.IsAuthError()
+}
@@ -0,0 +1,93 @@
+package gogo_boy
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestErrors(t *testing.T) {
+	after := func() {
+		StopMocks()
+	}
+
+	Convey("Campaign trigger failures are an APIError", t, func() {
+		defer after()
+
+		MockCampaignTriggerFailure(func(_request map[string]interface{}) {})
+
+		ctr := NewClient("foo").NewCampaignTriggerRequest("my-campaign-id")
+		ctr.AddRecipient("4900", nil)
+		err := ctr.Post()
+
+		var apiErr *APIError
+		So(errors.As(err, &apiErr), ShouldEqual, true)
+		So(apiErr.Op, ShouldEqual, "RawCampaignTriggerRequest")
+		So(apiErr.Method, ShouldEqual, "POST")
+		So(apiErr.Endpoint, ShouldEqual, CampaignTriggerEndpoint)
+		So(apiErr.StatusCode, ShouldEqual, 400)
+		So(apiErr.Message, ShouldEqual, "An error message")
+		So(err.Error(), ShouldStartWith, "RawCampaignTriggerRequest failed: app-boy answered POST "+CampaignTriggerPath+" with a 400")
+		So(IsRetryable(err), ShouldEqual, false)
+		So(IsRateLimited(err), ShouldEqual, false)
+		So(IsAuthError(err), ShouldEqual, false)
+	})
+
+	Convey("Parses the errors array of a track failure", t, func() {
+		defer after()
+
+		httpmock.Activate()
+		httpmock.RegisterResponder("POST", TrackEndpoint,
+			httpmock.NewStringResponder(400, getFixtureWithPath("track_res_err.json")))
+
+		_, err := RawPostTrackRequest(&RawTrackRequest{AppGroupId: "foo"})
+
+		var apiErr *APIError
+		So(errors.As(err, &apiErr), ShouldEqual, true)
+		So(len(apiErr.Errors), ShouldEqual, 2)
+		So(apiErr.Errors[0].InputArray, ShouldEqual, "attributes")
+		So(apiErr.Errors[0].Index, ShouldEqual, 0)
+		So(apiErr.Errors[1].Type, ShouldEqual, "Request body is too large")
+	})
+
+	Convey("Non-JSON failures keep the raw body", t, func() {
+		defer after()
+
+		MockTrackFailure(func(_request map[string]interface{}) {})
+
+		_, err := RawPostTrackRequest(&RawTrackRequest{AppGroupId: "foo"})
+
+		var apiErr *APIError
+		So(errors.As(err, &apiErr), ShouldEqual, true)
+		So(string(apiErr.Body), ShouldEqual, "error")
+		So(apiErr.Message, ShouldEqual, "")
+		So(IsRetryable(err), ShouldEqual, true)
+	})
+
+	Convey("Classifies status codes", t, func() {
+		So(IsRateLimited(&APIError{StatusCode: 429}), ShouldEqual, true)
+		So(IsRetryable(&APIError{StatusCode: 429}), ShouldEqual, true)
+		So(IsRetryable(&APIError{StatusCode: 503}), ShouldEqual, true)
+		So(IsRetryable(&APIError{StatusCode: 400}), ShouldEqual, false)
+		So(IsAuthError(&APIError{StatusCode: 401}), ShouldEqual, true)
+		So(IsAuthError(&APIError{StatusCode: 403}), ShouldEqual, true)
+
+		// Works through wrapping and with unrelated errors
+		So(IsRateLimited(fmt.Errorf("wrapped: %w", &APIError{StatusCode: 429})), ShouldEqual, true)
+		So(IsRetryable(errors.New("nope")), ShouldEqual, false)
+		So(IsAuthError(nil), ShouldEqual, false)
+	})
+
+	Convey("Recognises auth failures", t, func() {
+		rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return newStubResponse(401, `{"message":"Invalid API key"}`), nil
+		})
+
+		err := RawPostDeletePushTokenRequestWithTransport(NewTransport(WithRoundTripper(rt), WithAPIKey("bad")), &RawPushTokenDeleteRequest{})
+		So(IsAuthError(err), ShouldEqual, true)
+	})
+}
@@ -0,0 +1,143 @@
+package gogo_boy
+
+import (
+	"fmt"
+)
+
+/*
+	----------------------------------------------------------------------
+	Identifying users by something other than their external_id
+	----------------------------------------------------------------------
+*/
+
+type UserAlias struct {
+	AliasName  string `json:"alias_name"`
+	AliasLabel string `json:"alias_label"`
+}
+
+// Who a request is about, exactly one of the fields should be set. Use the
+// By* functions to build one.
+type Identifier struct {
+	ExternalId string
+	UserAlias  *UserAlias
+	BrazeId    string
+	Email      string
+}
+
+func ByExternalId(externalId string) Identifier {
+	return Identifier{ExternalId: externalId}
+}
+
+func ByUserAlias(name, label string) Identifier {
+	return Identifier{UserAlias: &UserAlias{AliasName: name, AliasLabel: label}}
+}
+
+// The id app-boy assigned the user
+func ByBrazeId(brazeId string) Identifier {
+	return Identifier{BrazeId: brazeId}
+}
+
+func ByEmail(email string) Identifier {
+	return Identifier{Email: email}
+}
+
+func (id Identifier) IsZero() bool {
+	return id.ExternalId == "" && id.UserAlias == nil && id.BrazeId == "" && id.Email == ""
+}
+
+// Formatted the way our error messages refer to users, e.g. "external_id: foo"
+func (id Identifier) String() string {
+	switch {
+	case id.ExternalId != "":
+		return "external_id: " + id.ExternalId
+	case id.UserAlias != nil:
+		return fmt.Sprintf("user_alias: %s/%s", id.UserAlias.AliasLabel, id.UserAlias.AliasName)
+	case id.BrazeId != "":
+		return "braze_id: " + id.BrazeId
+	case id.Email != "":
+		return "email: " + id.Email
+	}
+
+	return "no identifier"
+}
+
+func (id Identifier) validate() error {
+	set := 0
+	for _, isSet := range []bool{id.ExternalId != "", id.UserAlias != nil, id.BrazeId != "", id.Email != ""} {
+		if isSet {
+			set++
+		}
+	}
+
+	if set != 1 {
+		return fmt.Errorf("exactly one of external_id, user_alias, braze_id or email must identify the user but %d were set", set)
+	}
+	if id.UserAlias != nil && (id.UserAlias.AliasName == "" || id.UserAlias.AliasLabel == "") {
+		return fmt.Errorf("user_alias needs both a name and a label")
+	}
+
+	return nil
+}
+
+// Track requests made from a TrackRequest built with NewTrackRequest only
+// ever had an ExternalId
+func (tr *TrackRequest) identifier() Identifier {
+	if !tr.Identifier.IsZero() {
+		return tr.Identifier
+	}
+
+	return ByExternalId(tr.ExternalId)
+}
+
+func (c *AppClient) NewTrackRequestFor(id Identifier) *TrackRequest {
+	tr := c.NewTrackRequest(id.ExternalId)
+	tr.Identifier = id
+	return tr
+}
+
+// Campaigns can't be triggered by braze_id
+func (ctr *CampaignTriggerRequest) AddRecipientFor(id Identifier, triggerProperties map[string]interface{}) error {
+	if err := id.validate(); err != nil {
+		return err
+	}
+	if id.BrazeId != "" {
+		return fmt.Errorf("campaign recipients can't be identified by braze_id")
+	}
+
+	ctr.Recipients = append(ctr.Recipients, RawCampaignRecipient{
+		ExternalId:        id.ExternalId,
+		UserAlias:         id.UserAlias,
+		Email:             id.Email,
+		TriggerProperties: triggerProperties,
+	})
+	return nil
+}
+
+func (info *RawAttributesInfo) setIdentifier(id Identifier) {
+	info.ExternalId = id.ExternalId
+	info.UserAlias = id.UserAlias
+	info.BrazeId = id.BrazeId
+	if id.Email != "" {
+		info.Email = id.Email
+	}
+
+	// App-boy only creates alias only profiles when told to
+	if id.UserAlias != nil {
+		updateExistingOnly := false
+		info.UpdateExistingOnly = &updateExistingOnly
+	}
+}
+
+func (info *RawEventInfo) setIdentifier(id Identifier) {
+	info.ExternalId = id.ExternalId
+	info.UserAlias = id.UserAlias
+	info.BrazeId = id.BrazeId
+	info.Email = id.Email
+}
+
+func (info *RawPurchaseInfo) setIdentifier(id Identifier) {
+	info.ExternalId = id.ExternalId
+	info.UserAlias = id.UserAlias
+	info.BrazeId = id.BrazeId
+	info.Email = id.Email
+}
@@ -0,0 +1,127 @@
+package gogo_boy
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestIdentifiers(t *testing.T) {
+	var appClient *AppClient
+	before := func() {
+		appClient = NewClient("foo").NewAppClient("blah")
+	}
+
+	after := func() {
+		StopMocks()
+	}
+
+	Convey("Sends the user alias on every object of a track request", t, func() {
+		before()
+		defer after()
+
+		// Mock request to app-boy
+		var request map[string]interface{}
+		MockTrackSuccess(func(_request map[string]interface{}) { request = _request })
+
+		a := appClient.NewTrackRequestFor(ByUserAlias("anon-123", "device"))
+		a.SetFirstName("foo")
+		event := NewEvent()
+		event.SetName("viewed")
+		a.AddEvent(event)
+		purchase := NewPurchaseEvent()
+		purchase.SetProductId("bar")
+		purchase.SetCurrencyUSD()
+		a.AddEvent(purchase)
+		_, err := a.Post()
+		checkErr(err)
+
+		alias := map[string]interface{}{"alias_name": "anon-123", "alias_label": "device"}
+		attribute := request["attributes"].([]interface{})[0].(map[string]interface{})
+		So(attribute["user_alias"], ShouldResemble, alias)
+		So(attribute["_update_existing_only"], ShouldEqual, false)
+		So(attribute["first_name"], ShouldEqual, "foo")
+		_, ok := attribute["external_id"]
+		So(ok, ShouldEqual, false)
+
+		for _, key := range []string{"events", "purchases"} {
+			object := request[key].([]interface{})[0].(map[string]interface{})
+			So(object["user_alias"], ShouldResemble, alias)
+			_, ok := object["external_id"]
+			So(ok, ShouldEqual, false)
+		}
+	})
+
+	Convey("Serializes each kind of identifier into its own field", t, func() {
+		before()
+
+		rt, err := appClient.NewTrackRequestFor(ByBrazeId("5cd1")).rawTrackRequest()
+		checkErr(err)
+		So(rt.Attributes[0].BrazeId, ShouldEqual, "5cd1")
+		So(rt.Attributes[0].UpdateExistingOnly, ShouldBeNil)
+
+		a := appClient.NewTrackRequestFor(ByEmail("foo@example.com"))
+		event := NewEvent()
+		a.AddEvent(event)
+		rt, err = a.rawTrackRequest()
+		checkErr(err)
+		So(rt.Attributes[0].Email, ShouldEqual, "foo@example.com")
+		So(rt.Events[0].Email, ShouldEqual, "foo@example.com")
+
+		// NewTrackRequest keeps working off the external id
+		rt, err = appClient.NewTrackRequest("holah").rawTrackRequest()
+		checkErr(err)
+		So(rt.Attributes[0].ExternalId, ShouldEqual, "holah")
+	})
+
+	Convey("Rejects requests that don't identify exactly one user", t, func() {
+		before()
+
+		_, err := appClient.NewTrackRequest("").rawTrackRequest()
+		So(err, ShouldNotEqual, nil)
+
+		_, err = appClient.NewTrackRequestFor(Identifier{ExternalId: "holah", BrazeId: "5cd1"}).rawTrackRequest()
+		So(err, ShouldNotEqual, nil)
+
+		_, err = appClient.NewTrackRequestFor(ByUserAlias("anon-123", "")).rawTrackRequest()
+		So(err, ShouldNotEqual, nil)
+	})
+
+	Convey("The identifier survives a JSON round trip", t, func() {
+		before()
+
+		res, err := json.Marshal(appClient.NewTrackRequestFor(ByUserAlias("anon-123", "device")))
+		checkErr(err)
+		var b TrackRequest
+		checkErr(json.Unmarshal(res, &b))
+
+		rt, err := b.rawTrackRequest()
+		checkErr(err)
+		So(*rt.Attributes[0].UserAlias, ShouldResemble, UserAlias{AliasName: "anon-123", AliasLabel: "device"})
+	})
+
+	Convey("Campaign recipients can be identified by alias or email", t, func() {
+		before()
+		defer after()
+
+		// Mock request to app-boy
+		var request map[string]interface{}
+		MockCampaignTriggerSuccess(func(_request map[string]interface{}) { request = _request })
+
+		ctr := appClient.NewCampaignTriggerRequest("my-campaign-id")
+		checkErr(ctr.AddRecipientFor(ByUserAlias("anon-123", "device"), nil))
+		checkErr(ctr.AddRecipientFor(ByEmail("foo@example.com"), nil))
+		ctr.AddRecipient("holah", nil)
+		So(ctr.AddRecipientFor(ByBrazeId("5cd1"), nil), ShouldNotEqual, nil)
+		checkErr(ctr.Post())
+
+		recipients := request["recipients"].([]interface{})
+		So(len(recipients), ShouldEqual, 3)
+		So(recipients[0].(map[string]interface{})["user_alias"], ShouldResemble, map[string]interface{}{"alias_name": "anon-123", "alias_label": "device"})
+		_, ok := recipients[0].(map[string]interface{})["external_user_id"]
+		So(ok, ShouldEqual, false)
+		So(recipients[1].(map[string]interface{})["email"], ShouldEqual, "foo@example.com")
+		So(recipients[2].(map[string]interface{})["external_user_id"], ShouldEqual, "holah")
+	})
+}
@@ -0,0 +1,319 @@
+package gogo_boy
+
+import (
+	"context"
+	"fmt"
+)
+
+/*
+	----------------------------------------------------------------------
+	Raw requests for sending messages without a campaign
+	----------------------------------------------------------------------
+*/
+
+const (
+	MessagesSendPath = "/messages/send"
+
+	// Full URL on the default host
+	MessagesSendEndpoint = DefaultBaseURL + MessagesSendPath
+)
+
+// App-boy won't send a message to more recipients than this per request
+const MaxMessageRecipients = 50
+
+// Send messages straight to users. Either list ExternalUserIds/UserAliases or
+// set Broadcast to send to everyone in SegmentId (narrowed down by Audience
+// if set).
+type RawMessageSendRequest struct {
+	AppGroupId      string      `json:"app_group_id,omitempty"`
+	ExternalUserIds []string    `json:"external_user_ids,omitempty"`
+	UserAliases     []UserAlias `json:"user_aliases,omitempty"`
+	SegmentId       string      `json:"segment_id,omitempty"`
+	Audience        Audience    `json:"audience,omitempty"`
+	Broadcast       bool        `json:"broadcast,omitempty"`
+
+	// Optional, reports the send's analytics under this API campaign
+	CampaignId string `json:"campaign_id,omitempty"`
+	SendId     string `json:"send_id,omitempty"`
+
+	OverrideFrequencyCapping   bool   `json:"override_frequency_capping,omitempty"`
+	RecipientSubscriptionState string `json:"recipient_subscription_state,omitempty"` // "opted_in", "subscribed" or "all"
+
+	Messages Messages `json:"messages"`
+}
+
+// One message per channel, leave the channels you don't want out
+type Messages struct {
+	ApplePush   *ApplePushMessage   `json:"apple_push,omitempty"`
+	AndroidPush *AndroidPushMessage `json:"android_push,omitempty"`
+	Email       *EmailMessage       `json:"email,omitempty"`
+	SMS         *SMSMessage         `json:"sms,omitempty"`
+	Webhook     *WebhookMessage     `json:"webhook,omitempty"`
+	InApp       *InAppMessage       `json:"in_app_message,omitempty"`
+	ContentCard *ContentCardMessage `json:"content_card,omitempty"`
+}
+
+type ApplePushMessage struct {
+	Alert              string                 `json:"alert"`
+	Badge              *int                   `json:"badge,omitempty"`
+	Sound              string                 `json:"sound,omitempty"`
+	Category           string                 `json:"category,omitempty"`
+	Extra              map[string]interface{} `json:"extra,omitempty"`
+	ContentAvailable   bool                   `json:"content-available,omitempty"`
+	MutableContent     bool                   `json:"mutable_content,omitempty"`
+	CustomUri          string                 `json:"custom_uri,omitempty"`
+	CollapseId         string                 `json:"collapse_id,omitempty"`
+	Expiry             string                 `json:"expiry,omitempty"` // ISO 8601
+	AppId              string                 `json:"app_id,omitempty"`
+	MessageVariationId string                 `json:"message_variation_id,omitempty"`
+}
+
+type AndroidPushMessage struct {
+	Alert                 string                 `json:"alert"`
+	Title                 string                 `json:"title"`
+	Extra                 map[string]interface{} `json:"extra,omitempty"`
+	NotificationChannelId string                 `json:"notification_channel_id,omitempty"`
+	Priority              int                    `json:"priority,omitempty"` // -2 to 2
+	CustomUri             string                 `json:"custom_uri,omitempty"`
+	BigPictureUrl         string                 `json:"big_picture_url,omitempty"`
+	CollapseKey           string                 `json:"collapse_key,omitempty"`
+	TimeToLive            int                    `json:"time_to_live,omitempty"` // Seconds
+	SendToSync            bool                   `json:"send_to_sync,omitempty"`
+	AppId                 string                 `json:"app_id,omitempty"`
+	MessageVariationId    string                 `json:"message_variation_id,omitempty"`
+}
+
+// Body or EmailTemplateId is needed
+type EmailMessage struct {
+	AppId              string                 `json:"app_id"`
+	From               string                 `json:"from"` // "Name <address>"
+	ReplyTo            string                 `json:"reply_to,omitempty"`
+	Bcc                string                 `json:"bcc,omitempty"`
+	Subject            string                 `json:"subject,omitempty"`
+	Body               string                 `json:"body,omitempty"`
+	PlaintextBody      string                 `json:"plaintext_body,omitempty"`
+	Preheader          string                 `json:"preheader,omitempty"`
+	EmailTemplateId    string                 `json:"email_template_id,omitempty"`
+	Extras             map[string]interface{} `json:"extras,omitempty"`
+	Headers            map[string]string      `json:"headers,omitempty"`
+	Attachments        []EmailAttachment      `json:"attachments,omitempty"`
+	MessageVariationId string                 `json:"message_variation_id,omitempty"`
+}
+
+type EmailAttachment struct {
+	FileName string `json:"file_name"`
+	Url      string `json:"url"`
+}
+
+type SMSMessage struct {
+	SubscriptionGroupId string   `json:"subscription_group_id"`
+	Body                string   `json:"body"`
+	AppId               string   `json:"app_id,omitempty"`
+	MediaItems          []string `json:"media_items,omitempty"` // URLs, MMS only
+	MessageVariationId  string   `json:"message_variation_id,omitempty"`
+}
+
+type WebhookMessage struct {
+	Url                string            `json:"url"`
+	RequestMethod      string            `json:"request_method,omitempty"` // Defaults to POST
+	RequestHeaders     map[string]string `json:"request_headers,omitempty"`
+	Body               string            `json:"body,omitempty"`
+	MessageVariationId string            `json:"message_variation_id,omitempty"`
+}
+
+type InAppMessage struct {
+	Type               string                 `json:"type,omitempty"` // e.g. "SLIDEUP", "MODAL" or "FULL"
+	Message            string                 `json:"message"`
+	Header             string                 `json:"header,omitempty"`
+	ImageUrl           string                 `json:"image_url,omitempty"`
+	Uri                string                 `json:"uri,omitempty"`
+	Extras             map[string]interface{} `json:"extras,omitempty"`
+	AppId              string                 `json:"app_id,omitempty"`
+	MessageVariationId string                 `json:"message_variation_id,omitempty"`
+}
+
+type ContentCardMessage struct {
+	Type               string                 `json:"type"` // "CLASSIC", "CAPTIONED_IMAGE" or "BANNER"
+	Title              string                 `json:"title,omitempty"`
+	Description        string                 `json:"description"`
+	ImageUrl           string                 `json:"image_url,omitempty"`
+	Uri                string                 `json:"uri,omitempty"`
+	UriText            string                 `json:"uri_text,omitempty"`
+	UseWebview         bool                   `json:"use_webview,omitempty"`
+	Pinned             bool                   `json:"pinned,omitempty"`
+	Dismissible        *bool                  `json:"dismissible,omitempty"`
+	TimeToLive         int                    `json:"time_to_live,omitempty"` // Seconds
+	Extra              map[string]interface{} `json:"extra,omitempty"`
+	MessageVariationId string                 `json:"message_variation_id,omitempty"`
+}
+
+type MessageSendResponse struct {
+	DispatchId string `json:"dispatch_id"`
+	Message    string `json:"message"`
+}
+
+func RawPostMessageSendRequest(rawReq *RawMessageSendRequest) (*MessageSendResponse, error) {
+	return RawPostMessageSendRequestContext(context.Background(), nil, rawReq)
+}
+
+// Same as RawPostMessageSendRequest but sent over transport (nil for the
+// defaults) and aborts when ctx is done
+func RawPostMessageSendRequestContext(ctx context.Context, transport *Transport, rawReq *RawMessageSendRequest) (*MessageSendResponse, error) {
+	res := &MessageSendResponse{}
+	if err := transportOrDefault(transport).postJSON(ctx, "RawMessageSendRequest", MessagesSendPath, rawReq, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+/*
+	----------------------------------------------------------------------
+	Prettier version on Client
+	----------------------------------------------------------------------
+*/
+
+type MessageSendRequest struct {
+	AppGroupId string
+	CampaignId string
+	SendId     string // Optional, see Client.CreateSendId
+
+	Broadcast bool
+	SegmentId string
+	Audience  Audience
+
+	OverrideFrequencyCapping   bool
+	RecipientSubscriptionState string
+
+	ExternalUserIds []string
+	UserAliases     []UserAlias
+
+	Messages Messages
+
+	transport *Transport
+}
+
+func (c *Client) NewMessageSendRequest() *MessageSendRequest {
+	return &MessageSendRequest{
+		AppGroupId: c.appGroupId,
+		transport:  c.transport,
+	}
+}
+
+func (msr *MessageSendRequest) AddRecipient(externalId string) {
+	msr.ExternalUserIds = append(msr.ExternalUserIds, externalId)
+}
+
+// Messages can only be sent by external id or user alias
+func (msr *MessageSendRequest) AddRecipientFor(id Identifier) error {
+	if err := id.validate(); err != nil {
+		return err
+	}
+
+	switch {
+	case id.ExternalId != "":
+		msr.ExternalUserIds = append(msr.ExternalUserIds, id.ExternalId)
+	case id.UserAlias != nil:
+		msr.UserAliases = append(msr.UserAliases, *id.UserAlias)
+	default:
+		return fmt.Errorf("message recipients can only be identified by external_id or user_alias (%s)", id)
+	}
+	return nil
+}
+
+func (msr *MessageSendRequest) Send() (*MessageSendResponse, error) {
+	return msr.SendContext(context.Background())
+}
+
+// Same as Send but aborts when ctx is done
+func (msr *MessageSendRequest) SendContext(ctx context.Context) (*MessageSendResponse, error) {
+	if err := msr.validate(); err != nil {
+		return nil, err
+	}
+
+	return RawPostMessageSendRequestContext(ctx, msr.transport, msr.rawMessageSendRequest())
+}
+
+func (msr *MessageSendRequest) recipientCount() int {
+	return len(msr.ExternalUserIds) + len(msr.UserAliases)
+}
+
+func (msr *MessageSendRequest) validate() error {
+	lr := msr.recipientCount()
+	if msr.Broadcast && lr > 0 {
+		return fmt.Errorf("MessageSendRequest can't broadcast and list recipients at the same time")
+	}
+	if !msr.Broadcast && lr == 0 {
+		return fmt.Errorf("MessageSendRequest has no recipients, set Broadcast to send to the whole segment or audience")
+	}
+	if msr.Broadcast && msr.SegmentId == "" && msr.Audience == nil {
+		return fmt.Errorf("MessageSendRequest broadcasts need a SegmentId or Audience")
+	}
+	if lr > MaxMessageRecipients {
+		return fmt.Errorf("MessageSendRequest has %d recipients which exceeds the maximum of %d per request", lr, MaxMessageRecipients)
+	}
+	if msr.Audience != nil {
+		if err := msr.Audience.validate(); err != nil {
+			return fmt.Errorf("MessageSendRequest has an invalid audience: %s", err)
+		}
+	}
+	if len(msr.SendId) > MaxSendIdLength {
+		return fmt.Errorf("MessageSendRequest has a send_id longer than %d characters", MaxSendIdLength)
+	}
+	if err := msr.Messages.validate(); err != nil {
+		return fmt.Errorf("MessageSendRequest has an invalid message: %s", err)
+	}
+
+	return nil
+}
+
+func (msr *MessageSendRequest) rawMessageSendRequest() *RawMessageSendRequest {
+	return &RawMessageSendRequest{
+		AppGroupId:                 msr.AppGroupId,
+		ExternalUserIds:            msr.ExternalUserIds,
+		UserAliases:                msr.UserAliases,
+		SegmentId:                  msr.SegmentId,
+		Audience:                   msr.Audience,
+		Broadcast:                  msr.Broadcast,
+		CampaignId:                 msr.CampaignId,
+		SendId:                     msr.SendId,
+		OverrideFrequencyCapping:   msr.OverrideFrequencyCapping,
+		RecipientSubscriptionState: msr.RecipientSubscriptionState,
+		Messages:                   msr.Messages,
+	}
+}
+
+// Catches the required fields app-boy would reject the whole send for
+func (m Messages) validate() error {
+	if m == (Messages{}) {
+		return fmt.Errorf("there are no messages to send")
+	}
+	if m.ApplePush != nil && m.ApplePush.Alert == "" && !m.ApplePush.ContentAvailable {
+		return fmt.Errorf("apple_push needs an alert unless it's content-available")
+	}
+	if m.AndroidPush != nil && m.AndroidPush.Alert == "" {
+		return fmt.Errorf("android_push needs an alert")
+	}
+	if e := m.Email; e != nil {
+		if e.AppId == "" || e.From == "" {
+			return fmt.Errorf("email needs an app_id and from address")
+		}
+		if e.Body == "" && e.EmailTemplateId == "" {
+			return fmt.Errorf("email needs a body or email_template_id")
+		}
+	}
+	if m.SMS != nil && (m.SMS.SubscriptionGroupId == "" || m.SMS.Body == "") {
+		return fmt.Errorf("sms needs a subscription_group_id and body")
+	}
+	if m.Webhook != nil && m.Webhook.Url == "" {
+		return fmt.Errorf("webhook needs a url")
+	}
+	if m.InApp != nil && m.InApp.Message == "" {
+		return fmt.Errorf("in_app_message needs a message")
+	}
+	if m.ContentCard != nil && (m.ContentCard.Type == "" || m.ContentCard.Description == "") {
+		return fmt.Errorf("content_card needs a type and description")
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package gogo_boy
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMessagesAPI(t *testing.T) {
+	var client *Client
+	before := func() {
+		client = NewClient("foo")
+	}
+
+	after := func() {
+		StopMocks()
+	}
+
+	Convey("Can send messages to recipients", t, func() {
+		before()
+		defer after()
+
+		// Mock request to app-boy
+		var request map[string]interface{}
+		MockMessagesSendSuccess(func(_request map[string]interface{}) { request = _request })
+
+		badge := 1
+		msr := client.NewMessageSendRequest()
+		msr.AddRecipient("holah")
+		checkErr(msr.AddRecipientFor(ByUserAlias("anon-123", "device")))
+		So(msr.AddRecipientFor(ByEmail("a@b.com")), ShouldNotEqual, nil)
+		msr.Messages.ApplePush = &ApplePushMessage{Alert: "Your order shipped", Badge: &badge}
+		msr.Messages.Email = &EmailMessage{AppId: "app-1", From: "Shop <shop@example.com>", Subject: "Shipped", Body: "<p>Shipped</p>"}
+		msr.Messages.ContentCard = &ContentCardMessage{Type: "CLASSIC", Description: "Track your order"}
+
+		res, err := msr.Send()
+		checkErr(err)
+		So(res.DispatchId, ShouldEqual, "dispatch-2")
+		So(request["app_group_id"], ShouldEqual, "foo")
+		So(request["external_user_ids"], ShouldResemble, []interface{}{"holah"})
+		So(request["user_aliases"], ShouldResemble, []interface{}{
+			map[string]interface{}{"alias_name": "anon-123", "alias_label": "device"},
+		})
+		So(request["messages"], ShouldResemble, map[string]interface{}{
+			"apple_push":   map[string]interface{}{"alert": "Your order shipped", "badge": float64(1)},
+			"email":        map[string]interface{}{"app_id": "app-1", "from": "Shop <shop@example.com>", "subject": "Shipped", "body": "<p>Shipped</p>"},
+			"content_card": map[string]interface{}{"type": "CLASSIC", "description": "Track your order"},
+		})
+		_, ok := request["broadcast"]
+		So(ok, ShouldEqual, false)
+	})
+
+	Convey("Can broadcast messages to a segment", t, func() {
+		before()
+		defer after()
+
+		// Mock request to app-boy
+		var request map[string]interface{}
+		MockMessagesSendSuccess(func(_request map[string]interface{}) { request = _request })
+
+		msr := client.NewMessageSendRequest()
+		msr.Broadcast = true
+		msr.SegmentId = "my-segment-id"
+		msr.Audience = PushSubscriptionFilter(ComparisonIs, SubscriptionOptedIn)
+		msr.CampaignId = "my-api-campaign-id"
+		msr.SendId = "flash-sale"
+		msr.Messages.AndroidPush = &AndroidPushMessage{Alert: "50% off today", Title: "Flash sale"}
+		_, err := msr.Send()
+		checkErr(err)
+		So(request["broadcast"], ShouldEqual, true)
+		So(request["segment_id"], ShouldEqual, "my-segment-id")
+		So(request["campaign_id"], ShouldEqual, "my-api-campaign-id")
+		So(request["send_id"], ShouldEqual, "flash-sale")
+		So(request["audience"], ShouldNotBeNil)
+		_, ok := request["external_user_ids"]
+		So(ok, ShouldEqual, false)
+	})
+
+	Convey("Rejects sends app-boy wouldn't take", t, func() {
+		before()
+
+		msr := client.NewMessageSendRequest()
+		msr.Messages.Webhook = &WebhookMessage{Url: "https://example.com/hook"}
+		_, err := msr.Send()
+		So(err, ShouldNotEqual, nil)
+
+		msr.AddRecipient("holah")
+		msr.Broadcast = true
+		_, err = msr.Send()
+		So(err, ShouldNotEqual, nil)
+
+		msr = client.NewMessageSendRequest()
+		msr.Broadcast = true
+		msr.Messages.Webhook = &WebhookMessage{Url: "https://example.com/hook"}
+		_, err = msr.Send()
+		So(err, ShouldNotEqual, nil) // No segment or audience
+
+		msr = client.NewMessageSendRequest()
+		msr.AddRecipient("holah")
+		_, err = msr.Send()
+		So(err, ShouldNotEqual, nil) // No messages
+
+		for _, messages := range []Messages{
+			{ApplePush: &ApplePushMessage{}},
+			{Email: &EmailMessage{AppId: "app-1", From: "shop@example.com"}},
+			{SMS: &SMSMessage{Body: "hi"}},
+			{ContentCard: &ContentCardMessage{Type: "CLASSIC"}},
+		} {
+			msr.Messages = messages
+			_, err = msr.Send()
+			So(err, ShouldNotEqual, nil)
+		}
+
+		msr.Messages = Messages{ApplePush: &ApplePushMessage{ContentAvailable: true}}
+		for i := 0; i < MaxMessageRecipients; i++ {
+			msr.AddRecipient("holah")
+		}
+		_, err = msr.Send()
+		So(err, ShouldNotEqual, nil)
+	})
+
+	Convey("Failed sends return an APIError", t, func() {
+		before()
+		defer after()
+
+		MockMessagesSendFailure(func(_request map[string]interface{}) {})
+
+		msr := client.NewMessageSendRequest()
+		msr.AddRecipient("holah")
+		msr.Messages.SMS = &SMSMessage{SubscriptionGroupId: "sms-group", Body: "hi"}
+		_, err := msr.Send()
+
+		var apiErr *APIError
+		So(errors.As(err, &apiErr), ShouldEqual, true)
+		So(apiErr.StatusCode, ShouldEqual, 400)
+	})
+}
@@ -0,0 +1,155 @@
+package gogo_boy
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+/*
+	----------------------------------------------------------------------
+	Raw requests for scheduling messages sent without a campaign
+	----------------------------------------------------------------------
+*/
+
+const (
+	MessagesScheduleCreatePath = "/messages/schedule/create"
+	MessagesScheduleUpdatePath = "/messages/schedule/update"
+	MessagesScheduleDeletePath = "/messages/schedule/delete"
+	ScheduledBroadcastsPath    = "/messages/scheduled_broadcasts"
+
+	// Full URLs on the default host
+	MessagesScheduleCreateEndpoint = DefaultBaseURL + MessagesScheduleCreatePath
+	MessagesScheduleUpdateEndpoint = DefaultBaseURL + MessagesScheduleUpdatePath
+	MessagesScheduleDeleteEndpoint = DefaultBaseURL + MessagesScheduleDeletePath
+	ScheduledBroadcastsEndpoint    = DefaultBaseURL + ScheduledBroadcastsPath
+)
+
+// A message send that goes out at Schedule instead of right away
+type RawScheduledMessageSendRequest struct {
+	RawMessageSendRequest
+	Schedule RawSchedule `json:"schedule"`
+}
+
+// A campaign, Canvas or message broadcast that's scheduled to go out
+type ScheduledBroadcast struct {
+	Name         string   `json:"name"`
+	Id           string   `json:"id"`
+	Type         string   `json:"type"` // "Campaign" or "Canvas"
+	Tags         []string `json:"tags"`
+	NextSendTime string   `json:"next_send_time"`
+	ScheduleType string   `json:"schedule_type"`
+}
+
+type ScheduledBroadcastsResponse struct {
+	ScheduledBroadcasts []ScheduledBroadcast `json:"scheduled_broadcasts"`
+	Message             string               `json:"message"`
+}
+
+func RawPostScheduledMessageSendRequest(rawReq *RawScheduledMessageSendRequest) (*ScheduleResponse, error) {
+	return RawPostScheduledMessageSendRequestContext(context.Background(), nil, rawReq)
+}
+
+// Same as RawPostScheduledMessageSendRequest but sent over transport (nil for
+// the defaults) and aborts when ctx is done
+func RawPostScheduledMessageSendRequestContext(ctx context.Context, transport *Transport, rawReq *RawScheduledMessageSendRequest) (*ScheduleResponse, error) {
+	res := &ScheduleResponse{}
+	if err := transportOrDefault(transport).postJSON(ctx, "RawScheduledMessageSendRequest", MessagesScheduleCreatePath, rawReq, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Lists broadcasts scheduled to go out before endTime. appGroupId is only
+// needed without a REST API key.
+func RawGetScheduledBroadcasts(appGroupId string, endTime time.Time) (*ScheduledBroadcastsResponse, error) {
+	return RawGetScheduledBroadcastsContext(context.Background(), nil, appGroupId, endTime)
+}
+
+// Same as RawGetScheduledBroadcasts but sent over transport (nil for the
+// defaults) and aborts when ctx is done
+func RawGetScheduledBroadcastsContext(ctx context.Context, transport *Transport, appGroupId string, endTime time.Time) (*ScheduledBroadcastsResponse, error) {
+	query := url.Values{"end_time": {endTime.UTC().Format(time.RFC3339)}}
+	if appGroupId != "" {
+		query.Set("app_group_id", appGroupId)
+	}
+
+	res := &ScheduledBroadcastsResponse{}
+	if err := transportOrDefault(transport).getJSON(ctx, "RawGetScheduledBroadcasts", ScheduledBroadcastsPath, query, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+/*
+	----------------------------------------------------------------------
+	Prettier versions on MessageSendRequest and Client
+	----------------------------------------------------------------------
+*/
+
+// Same as Send but sends the messages at schedule, the returned ScheduleId
+// is what you update or delete it by
+func (msr *MessageSendRequest) Schedule(schedule Schedule) (*ScheduleResponse, error) {
+	return msr.ScheduleContext(context.Background(), schedule)
+}
+
+// Same as Schedule but aborts when ctx is done
+func (msr *MessageSendRequest) ScheduleContext(ctx context.Context, schedule Schedule) (*ScheduleResponse, error) {
+	rs, err := schedule.raw()
+	if err != nil {
+		return nil, fmt.Errorf("Schedule for the MessageSendRequest failed: %s", err)
+	}
+	if err := msr.validate(); err != nil {
+		return nil, err
+	}
+
+	return RawPostScheduledMessageSendRequestContext(ctx, msr.transport, &RawScheduledMessageSendRequest{
+		RawMessageSendRequest: *msr.rawMessageSendRequest(),
+		Schedule:              rs,
+	})
+}
+
+// Moves the scheduled send to schedule, and replaces its messages unless
+// messages is nil
+func (c *Client) UpdateMessageSchedule(scheduleId string, schedule Schedule, messages *Messages) error {
+	return c.UpdateMessageScheduleContext(context.Background(), scheduleId, schedule, messages)
+}
+
+// Same as UpdateMessageSchedule but aborts when ctx is done
+func (c *Client) UpdateMessageScheduleContext(ctx context.Context, scheduleId string, schedule Schedule, messages *Messages) error {
+	if messages != nil {
+		if err := messages.validate(); err != nil {
+			return fmt.Errorf("RawScheduleUpdateRequest for (schedule_id: %s) failed: %s", scheduleId, err)
+		}
+	}
+
+	return c.updateSchedule(ctx, MessagesScheduleUpdatePath, &RawScheduleUpdateRequest{ScheduleId: scheduleId, Messages: messages}, schedule)
+}
+
+// Cancels the scheduled send
+func (c *Client) DeleteMessageSchedule(scheduleId string) error {
+	return c.DeleteMessageScheduleContext(context.Background(), scheduleId)
+}
+
+// Same as DeleteMessageSchedule but aborts when ctx is done
+func (c *Client) DeleteMessageScheduleContext(ctx context.Context, scheduleId string) error {
+	return c.deleteSchedule(ctx, MessagesScheduleDeletePath, &RawScheduleDeleteRequest{ScheduleId: scheduleId})
+}
+
+// Campaigns, Canvases and message sends scheduled to go out before endTime
+func (c *Client) ScheduledBroadcasts(endTime time.Time) ([]ScheduledBroadcast, error) {
+	return c.ScheduledBroadcastsContext(context.Background(), endTime)
+}
+
+// Same as ScheduledBroadcasts but aborts when ctx is done
+func (c *Client) ScheduledBroadcastsContext(ctx context.Context, endTime time.Time) ([]ScheduledBroadcast, error) {
+	res, err := RawGetScheduledBroadcastsContext(ctx, c.transport, c.appGroupId, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.ScheduledBroadcasts, nil
+}
@@ -0,0 +1,111 @@
+package gogo_boy
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMessagesScheduleAPI(t *testing.T) {
+	var client *Client
+	before := func() {
+		client = NewClient("foo")
+	}
+
+	after := func() {
+		StopMocks()
+	}
+
+	at := time.Date(2030, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	Convey("Can schedule messages", t, func() {
+		before()
+		defer after()
+
+		// Mock request to app-boy
+		var request map[string]interface{}
+		MockMessagesScheduleCreateSuccess(func(_request map[string]interface{}) { request = _request })
+
+		msr := client.NewMessageSendRequest()
+		msr.AddRecipient("holah")
+		msr.Messages.ApplePush = &ApplePushMessage{Alert: "Don't forget your appointment"}
+		res, err := msr.Schedule(Schedule{Time: at})
+		checkErr(err)
+		So(res.ScheduleId, ShouldEqual, "schedule-1")
+		So(request["external_user_ids"], ShouldResemble, []interface{}{"holah"})
+		So(request["messages"], ShouldResemble, map[string]interface{}{
+			"apple_push": map[string]interface{}{"alert": "Don't forget your appointment"},
+		})
+		So(request["schedule"], ShouldResemble, map[string]interface{}{"time": "2030-01-02T15:04:05Z"})
+
+		// Invalid sends aren't scheduled
+		_, err = client.NewMessageSendRequest().Schedule(Schedule{Time: at})
+		So(err, ShouldNotEqual, nil)
+		_, err = msr.Schedule(Schedule{})
+		So(err, ShouldNotEqual, nil)
+	})
+
+	Convey("Can update and delete scheduled messages", t, func() {
+		before()
+		defer after()
+
+		// Mock requests to app-boy
+		var updateRequest, deleteRequest map[string]interface{}
+		MockScheduleChangeSuccess(MessagesScheduleUpdateEndpoint, func(_request map[string]interface{}) { updateRequest = _request })
+		MockScheduleChangeSuccess(MessagesScheduleDeleteEndpoint, func(_request map[string]interface{}) { deleteRequest = _request })
+
+		checkErr(client.UpdateMessageSchedule("schedule-1", Schedule{Time: at}, nil))
+		So(updateRequest["schedule_id"], ShouldEqual, "schedule-1")
+		So(updateRequest["app_group_id"], ShouldEqual, "foo")
+		_, ok := updateRequest["messages"]
+		So(ok, ShouldEqual, false)
+
+		checkErr(client.UpdateMessageSchedule("schedule-1", Schedule{Time: at}, &Messages{SMS: &SMSMessage{SubscriptionGroupId: "sms-group", Body: "See you at 3"}}))
+		So(updateRequest["messages"], ShouldResemble, map[string]interface{}{
+			"sms": map[string]interface{}{"subscription_group_id": "sms-group", "body": "See you at 3"},
+		})
+		So(client.UpdateMessageSchedule("schedule-1", Schedule{Time: at}, &Messages{}), ShouldNotEqual, nil)
+		So(client.UpdateMessageSchedule("", Schedule{Time: at}, nil), ShouldNotEqual, nil)
+
+		checkErr(client.DeleteMessageSchedule("schedule-1"))
+		So(deleteRequest, ShouldResemble, map[string]interface{}{"app_group_id": "foo", "schedule_id": "schedule-1"})
+		So(client.DeleteMessageSchedule(""), ShouldNotEqual, nil)
+	})
+
+	Convey("Can list scheduled broadcasts", t, func() {
+		before()
+		defer after()
+
+		// Mock request to app-boy
+		var query url.Values
+		MockScheduledBroadcastsSuccess(func(_query url.Values) { query = _query })
+
+		broadcasts, err := client.ScheduledBroadcasts(at)
+		checkErr(err)
+		So(query.Get("end_time"), ShouldEqual, "2030-01-02T15:04:05Z")
+		So(query.Get("app_group_id"), ShouldEqual, "foo")
+		So(len(broadcasts), ShouldEqual, 2)
+		So(broadcasts[0].Id, ShouldEqual, "my-campaign-id")
+		So(broadcasts[0].Type, ShouldEqual, "Campaign")
+		So(broadcasts[0].Tags, ShouldResemble, []string{"reminders"})
+		So(broadcasts[1].Type, ShouldEqual, "Canvas")
+	})
+
+	Convey("Failed listings return an APIError", t, func() {
+		before()
+		defer after()
+
+		MockScheduledBroadcastsFailure(func(_query url.Values) {})
+
+		_, err := client.ScheduledBroadcasts(at)
+
+		var apiErr *APIError
+		So(errors.As(err, &apiErr), ShouldEqual, true)
+		So(apiErr.StatusCode, ShouldEqual, 400)
+		So(err.Error(), ShouldContainSubstring, "GET "+ScheduledBroadcastsPath+" with a 400")
+		So(err.Error(), ShouldNotEndWith, "\n")
+	})
+}
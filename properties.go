@@ -0,0 +1,162 @@
+package gogo_boy
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+/*
+	----------------------------------------------------------------------
+	Event and purchase properties
+	----------------------------------------------------------------------
+*/
+
+// Properties of an event or purchase. Values may be strings, numbers, bools,
+// time.Time, nil, or slices and string keyed maps of those.
+type Properties map[string]interface{}
+
+const (
+	// Limits app-boy places on properties
+	MaxPropertyKeyLength    = 255
+	MaxPropertyStringLength = 255
+	MaxPropertiesSize       = 50 * 1024 // Bytes of JSON
+)
+
+// Keys app-boy uses itself and won't accept as properties
+var (
+	reservedEventPropertyKeys = map[string]bool{
+		"time":       true,
+		"event_name": true,
+	}
+	reservedPurchasePropertyKeys = map[string]bool{
+		"time":       true,
+		"event_name": true,
+		"product_id": true,
+		"quantity":   true,
+		"price":      true,
+		"currency":   true,
+	}
+)
+
+func (e *Event) SetProperty(key string, value interface{}) error {
+	if err := setProperty(&e.Properties, reservedEventPropertyKeys, key, value); err != nil {
+		return fmt.Errorf("event %q: %s", e.Name, err)
+	}
+
+	return nil
+}
+
+func (e *PurchaseEvent) SetProperty(key string, value interface{}) error {
+	if err := setProperty(&e.Properties, reservedPurchasePropertyKeys, key, value); err != nil {
+		return fmt.Errorf("purchase %q: %s", e.ProductId, err)
+	}
+
+	return nil
+}
+
+// Only sets value if the properties would still be valid with it
+func setProperty(properties *Properties, reserved map[string]bool, key string, value interface{}) error {
+	if *properties == nil {
+		*properties = Properties{}
+	}
+
+	previous, existed := (*properties)[key]
+	(*properties)[key] = value
+	if err := properties.validate(reserved); err != nil {
+		if existed {
+			(*properties)[key] = previous
+		} else {
+			delete(*properties, key)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (p Properties) validate(reserved map[string]bool) error {
+	for k, v := range p {
+		if reserved[k] {
+			return fmt.Errorf("property %q is reserved by app-boy", k)
+		}
+		if err := validatePropertyKey(k); err != nil {
+			return err
+		}
+		if err := validatePropertyValue(k, v); err != nil {
+			return err
+		}
+	}
+
+	_json, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("properties can't be marshalled: %s", err)
+	}
+	if len(_json) > MaxPropertiesSize {
+		return fmt.Errorf("properties are %d bytes, the maximum is %d", len(_json), MaxPropertiesSize)
+	}
+
+	return nil
+}
+
+func validatePropertyKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("property keys can't be empty")
+	}
+	if utf8.RuneCountInString(key) > MaxPropertyKeyLength {
+		return fmt.Errorf("property %q is longer than %d characters", key, MaxPropertyKeyLength)
+	}
+	if strings.HasPrefix(key, "$") {
+		return fmt.Errorf("property %q can't start with $", key)
+	}
+
+	return nil
+}
+
+// Walks nested objects and arrays, path is only used for error messages
+func validatePropertyValue(path string, value interface{}) error {
+	switch v := value.(type) {
+	case nil, bool, json.Number, time.Time, *time.Time:
+		return nil
+	case string:
+		if utf8.RuneCountInString(v) > MaxPropertyStringLength {
+			return fmt.Errorf("property %q is longer than %d characters", path, MaxPropertyStringLength)
+		}
+		return nil
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return nil
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if err := validatePropertyValue(fmt.Sprintf("%s[%d]", path, i), rv.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("property %q is a map without string keys", path)
+		}
+		iter := rv.MapRange()
+		for iter.Next() {
+			k := iter.Key().String()
+			if err := validatePropertyKey(k); err != nil {
+				return fmt.Errorf("in %q: %s", path, err)
+			}
+			if err := validatePropertyValue(path+"."+k, iter.Value().Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return fmt.Errorf("property %q is a %T which app-boy doesn't accept", path, value)
+}
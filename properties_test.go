@@ -0,0 +1,111 @@
+package gogo_boy
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestProperties(t *testing.T) {
+	var appClient *AppClient
+	before := func() {
+		appClient = NewClient("foo").NewAppClient("blah")
+	}
+
+	after := func() {
+		StopMocks()
+	}
+
+	Convey("Sends event and purchase properties", t, func() {
+		before()
+		defer after()
+
+		// Mock request to app-boy
+		var request map[string]interface{}
+		MockTrackSuccess(func(_request map[string]interface{}) { request = _request })
+
+		a := appClient.NewTrackRequest("holah")
+		event := NewEvent()
+		event.SetName("viewed")
+		checkErr(event.SetProperty("screen", "home"))
+		checkErr(event.SetProperty("count", 3))
+		checkErr(event.SetProperty("at", time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)))
+		checkErr(event.SetProperty("tags", []string{"a", "b"}))
+		checkErr(event.SetProperty("plan", map[string]interface{}{"name": "pro", "seats": 5}))
+		a.AddEvent(event)
+
+		purchase := NewPurchaseEvent()
+		purchase.SetProductId("bar")
+		purchase.SetCurrencyUSD()
+		checkErr(purchase.SetProperty("plan", "pro"))
+		a.AddEvent(purchase)
+
+		// Events without properties don't send any
+		plain := NewEvent()
+		plain.SetName("plain")
+		a.AddEvent(plain)
+
+		_, err := a.Post()
+		checkErr(err)
+
+		events := request["events"].([]interface{})
+		So(events[0].(map[string]interface{})["properties"], ShouldResemble, map[string]interface{}{
+			"screen": "home",
+			"count":  3.0,
+			"at":     "2020-01-02T03:04:05Z",
+			"tags":   []interface{}{"a", "b"},
+			"plan":   map[string]interface{}{"name": "pro", "seats": 5.0},
+		})
+		_, ok := events[1].(map[string]interface{})["properties"]
+		So(ok, ShouldEqual, false)
+
+		purchases := request["purchases"].([]interface{})
+		So(purchases[0].(map[string]interface{})["properties"], ShouldResemble, map[string]interface{}{"plan": "pro"})
+	})
+
+	Convey("SetProperty rejects what app-boy doesn't accept", t, func() {
+		event := NewEvent()
+		So(event.SetProperty("", 1), ShouldNotEqual, nil)
+		So(event.SetProperty("$screen", 1), ShouldNotEqual, nil)
+		So(event.SetProperty(strings.Repeat("a", 256), 1), ShouldNotEqual, nil)
+		So(event.SetProperty("name", strings.Repeat("a", 256)), ShouldNotEqual, nil)
+		So(event.SetProperty("time", "now"), ShouldNotEqual, nil)
+		So(event.SetProperty("fn", func() {}), ShouldNotEqual, nil)
+		So(event.SetProperty("nested", map[string]interface{}{"$bad": 1}), ShouldNotEqual, nil)
+		So(event.SetProperty("nested", []interface{}{struct{}{}}), ShouldNotEqual, nil)
+		So(len(event.Properties), ShouldEqual, 0)
+
+		// Reserved keys differ between events and purchases
+		So(event.SetProperty("price", 1), ShouldEqual, nil)
+		purchase := NewPurchaseEvent()
+		So(purchase.SetProperty("price", 1), ShouldNotEqual, nil)
+
+		// A value that would push the properties over 50KB isn't kept, and
+		// doesn't replace the old value
+		event.Properties = Properties{"big": "a"}
+		for i := 0; i < 250; i++ {
+			event.Properties[fmt.Sprintf("key%d", i)] = strings.Repeat("a", 200)
+		}
+		err := event.SetProperty("big", strings.Repeat("b", 255))
+		So(err, ShouldNotEqual, nil)
+		So(err.Error(), ShouldContainSubstring, "maximum")
+		So(event.Properties["big"], ShouldEqual, "a")
+	})
+
+	Convey("Properties set directly are validated when posting", t, func() {
+		before()
+
+		a := appClient.NewTrackRequest("holah")
+		event := NewEvent()
+		event.SetName("viewed")
+		event.Properties = Properties{"$bad": 1}
+		a.AddEvent(event)
+
+		_, err := a.Post()
+		So(err, ShouldNotEqual, nil)
+		So(err.Error(), ShouldContainSubstring, "$bad")
+	})
+}
@@ -0,0 +1,610 @@
+package gogo_boy
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+/*
+	----------------------------------------------------------------------
+	Durable queue for posting requests later
+	----------------------------------------------------------------------
+*/
+
+// What kind of request a QueueEntry's payload holds
+const (
+	QueueKindTrack           = "track"
+	QueueKindCampaignTrigger = "campaign_trigger"
+	QueueKindCanvasTrigger   = "canvas_trigger"
+	QueueKindPushTokenDelete = "push_token_delete"
+)
+
+type QueueEntry struct {
+	Id        uint64          `json:"id"` // Assigned by the queue
+	Kind      string          `json:"kind"`
+	Payload   json.RawMessage `json:"payload"` // The JSON marshalled request
+	Attempts  int             `json:"attempts"`
+	LastError string          `json:"last_error,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// Storage for requests waiting to be posted. Implementations must be safe
+// for concurrent use.
+type Queue interface {
+	// Store entry and return it with its Id filled in
+	Push(entry QueueEntry) (QueueEntry, error)
+
+	// Entries that are neither acked nor dead, oldest first
+	Pending() ([]QueueEntry, error)
+
+	// The entry was posted, forget about it
+	Ack(id uint64) error
+
+	// Posting failed but may work later
+	Retry(id uint64, err error) error
+
+	// Posting will never work, move the entry out of the way
+	DeadLetter(id uint64, err error) error
+
+	Close() error
+}
+
+// Requests are checked like Post would before they're written to the queue,
+// so the worker doesn't find out they're invalid later. Push tokens to delete
+// go in an entry of their own, so the track part isn't sent again when only
+// the delete failed.
+func EnqueueTrackRequest(q Queue, tr *TrackRequest) error {
+	if _, err := tr.rawTrackRequest(); err != nil {
+		return err
+	}
+
+	track := *tr
+	track.DeletePushTokenAttributes = nil
+	if err := enqueue(q, QueueKindTrack, &track); err != nil {
+		return err
+	}
+	if len(tr.DeletePushTokenAttributes) == 0 {
+		return nil
+	}
+
+	return enqueue(q, QueueKindPushTokenDelete, &RawPushTokenDeleteRequest{
+		AppGroupId: tr.AppGroupId,
+		PushTokens: tr.rawDeletePushTokens(),
+	})
+}
+
+// The worker posts each entry as one request, so triggers with more
+// recipients than app-boy takes at once are refused here rather than dead
+// lettered later. Enqueue one trigger per batch of recipients instead.
+func EnqueueCampaignTriggerRequest(q Queue, ctr *CampaignTriggerRequest) error {
+	if err := ctr.validate(); err != nil {
+		return err
+	}
+	if lr := len(ctr.Recipients); lr > MaxCampaignTriggerRecipients {
+		return fmt.Errorf("Tried to enqueue a CampaignTriggerRequest for the [AppBoyCampaign](campaign_id: %s) but there were %d recipients which exceeds the maximum of %d per request", ctr.CampaignId, lr, MaxCampaignTriggerRecipients)
+	}
+
+	return enqueue(q, QueueKindCampaignTrigger, ctr)
+}
+
+// Same checks as EnqueueCampaignTriggerRequest
+func EnqueueCanvasTriggerRequest(q Queue, ctr *CanvasTriggerRequest) error {
+	if err := ctr.validate(); err != nil {
+		return err
+	}
+	if lr := len(ctr.Recipients); lr > MaxCanvasTriggerRecipients {
+		return fmt.Errorf("Tried to enqueue a CanvasTriggerRequest for the [AppBoyCanvas](canvas_id: %s) but there were %d recipients which exceeds the maximum of %d per request", ctr.CanvasId, lr, MaxCanvasTriggerRecipients)
+	}
+
+	return enqueue(q, QueueKindCanvasTrigger, ctr)
+}
+
+func enqueue(q Queue, kind string, req interface{}) error {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("Enqueue failed to marshal the %s request: %s", kind, err)
+	}
+
+	_, err = q.Push(QueueEntry{
+		Kind:      kind,
+		Payload:   payload,
+		CreatedAt: time.Now().UTC(),
+	})
+	return err
+}
+
+/*
+	File backed queue
+*/
+
+// A Queue kept in an append-only log so it survives restarts. Every change is
+// a JSON line that's synced to disk before the call returns; opening the file
+// replays the log. Dead letters go to a second file next to it.
+type FileQueue struct {
+	mu       sync.Mutex
+	path     string
+	log      *os.File
+	dead     *os.File
+	nextId   uint64
+	entries  map[uint64]*QueueEntry
+	appended int // Records in the log since it was last compacted
+}
+
+type fileQueueRecord struct {
+	Op    string      `json:"op"` // push, ack, retry or dead
+	Id    uint64      `json:"id"`
+	Entry *QueueEntry `json:"entry,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
+
+// Rewrite the log once it has this many records beyond what's pending
+const fileQueueCompactAfter = 1000
+
+// Opens (or creates) the queue at path, dead letters go to path + ".dead"
+func NewFileQueue(path string) (*FileQueue, error) {
+	q := &FileQueue{
+		path:    path,
+		nextId:  1,
+		entries: map[uint64]*QueueEntry{},
+	}
+
+	if err := q.replay(); err != nil {
+		return nil, err
+	}
+
+	// Start every session with a compact log
+	if err := q.compact(); err != nil {
+		return nil, err
+	}
+
+	dead, err := os.OpenFile(q.DeadLetterPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		q.log.Close()
+		return nil, fmt.Errorf("NewFileQueue failed to open the dead letter file: %s", err)
+	}
+	q.dead = dead
+
+	return q, nil
+}
+
+func (q *FileQueue) DeadLetterPath() string {
+	return q.path + ".dead"
+}
+
+func (q *FileQueue) replay() error {
+	f, err := os.Open(q.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("NewFileQueue failed to open the log: %s", err)
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// A line without a newline was torn by a crash mid-write, it was
+			// never acknowledged to the caller so it's safe to drop
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("NewFileQueue failed to read the log: %s", err)
+		}
+
+		var record fileQueueRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return fmt.Errorf("NewFileQueue found a corrupt record in %s: %s", q.path, err)
+		}
+		q.apply(record)
+	}
+}
+
+func (q *FileQueue) apply(record fileQueueRecord) {
+	switch record.Op {
+	case "push":
+		entry := *record.Entry
+		q.entries[entry.Id] = &entry
+		if entry.Id >= q.nextId {
+			q.nextId = entry.Id + 1
+		}
+	case "retry":
+		if entry := q.entries[record.Id]; entry != nil {
+			entry.Attempts++
+			entry.LastError = record.Error
+		}
+	case "ack", "dead":
+		delete(q.entries, record.Id)
+	}
+}
+
+// Rewrites the log with only the pending entries. The new log is written
+// next to the old one and renamed over it so a crash leaves one or the other.
+// The old log stays open until the rename went through, so a failed
+// compaction leaves the queue as it was.
+func (q *FileQueue) compact() error {
+	tmpPath := q.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("FileQueue failed to compact: %s", err)
+	}
+
+	if err := q.writeCompacted(tmp); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("FileQueue failed to compact: %s", err)
+	}
+	if err := os.Rename(tmpPath, q.path); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("FileQueue failed to compact: %s", err)
+	}
+
+	// The file we just wrote is the log now, keep appending to it
+	if q.log != nil {
+		q.log.Close()
+	}
+	q.log = tmp
+	q.appended = 0
+
+	return nil
+}
+
+func (q *FileQueue) writeCompacted(f *os.File) error {
+	w := bufio.NewWriter(f)
+	for _, entry := range q.sorted() {
+		entry := entry
+		line, err := json.Marshal(fileQueueRecord{Op: "push", Id: entry.Id, Entry: &entry})
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	return f.Sync()
+}
+
+func (q *FileQueue) sorted() []QueueEntry {
+	entries := []QueueEntry{}
+	for _, entry := range q.entries {
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Id < entries[j].Id })
+
+	return entries
+}
+
+func (q *FileQueue) append(f *os.File, record interface{}) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+
+	return f.Sync()
+}
+
+// Writes record to the log and applies it. Once the record is in the log
+// the change has happened, so a failed compaction isn't returned, it's tried
+// again on the next commit.
+func (q *FileQueue) commit(record fileQueueRecord) error {
+	if q.log == nil {
+		return errors.New("FileQueue is closed")
+	}
+	if err := q.append(q.log, record); err != nil {
+		return fmt.Errorf("FileQueue failed to write to the log: %s", err)
+	}
+	q.apply(record)
+
+	q.appended++
+	if q.appended > fileQueueCompactAfter+len(q.entries) {
+		q.compact()
+	}
+
+	return nil
+}
+
+func (q *FileQueue) Push(entry QueueEntry) (QueueEntry, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry.Id = q.nextId
+	if err := q.commit(fileQueueRecord{Op: "push", Id: entry.Id, Entry: &entry}); err != nil {
+		return entry, err
+	}
+
+	return entry, nil
+}
+
+func (q *FileQueue) Pending() ([]QueueEntry, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.sorted(), nil
+}
+
+func (q *FileQueue) Ack(id uint64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.commit(fileQueueRecord{Op: "ack", Id: id})
+}
+
+func (q *FileQueue) Retry(id uint64, err error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.commit(fileQueueRecord{Op: "retry", Id: id, Error: errorString(err)})
+}
+
+// The entry is written to the dead letter file before it's removed from the
+// log, a crash in between means it's in both rather than neither
+func (q *FileQueue) DeadLetter(id uint64, err error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry := q.entries[id]
+	if entry == nil {
+		return fmt.Errorf("FileQueue has no pending entry %d", id)
+	}
+
+	dead := *entry
+	dead.LastError = errorString(err)
+	if err := q.append(q.dead, dead); err != nil {
+		return fmt.Errorf("FileQueue failed to write to the dead letter file: %s", err)
+	}
+
+	return q.commit(fileQueueRecord{Op: "dead", Id: id, Error: dead.LastError})
+}
+
+// Retry and DeadLetter take a nil error when there's nothing to say
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return err.Error()
+}
+
+// Everything that was ever dead lettered, oldest first
+func (q *FileQueue) DeadLetters() ([]QueueEntry, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	f, err := os.Open(q.DeadLetterPath())
+	if err != nil {
+		return nil, fmt.Errorf("FileQueue failed to open the dead letter file: %s", err)
+	}
+	defer f.Close()
+
+	entries := []QueueEntry{}
+	decoder := json.NewDecoder(f)
+	for {
+		var entry QueueEntry
+		if err := decoder.Decode(&entry); err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return entries, fmt.Errorf("FileQueue failed to read the dead letter file: %s", err)
+		}
+		entries = append(entries, entry)
+	}
+}
+
+func (q *FileQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.log == nil {
+		return nil
+	}
+
+	err := q.log.Close()
+	q.log = nil
+	if deadErr := q.dead.Close(); err == nil {
+		err = deadErr
+	}
+
+	return err
+}
+
+/*
+	Worker
+*/
+
+type QueueWorkerConfig struct {
+	// Dead letter an entry after this many failed attempts, defaults to 5
+	MaxAttempts int
+
+	// How long Run waits between passes over the queue, defaults to a second
+	PollInterval time.Duration
+}
+
+// Posts queued requests through a client's transport
+type QueueWorker struct {
+	client *Client
+	queue  Queue
+	config QueueWorkerConfig
+}
+
+func (c *Client) NewQueueWorker(q Queue, config QueueWorkerConfig) *QueueWorker {
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 5
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = time.Second
+	}
+
+	return &QueueWorker{
+		client: c,
+		queue:  q,
+		config: config,
+	}
+}
+
+// Drains the queue until ctx is done, failed entries are retried on the
+// next pass
+func (w *QueueWorker) Run(ctx context.Context) error {
+	for {
+		if err := w.Drain(ctx); err != nil {
+			return err
+		}
+
+		timer := time.NewTimer(w.config.PollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Makes one attempt at every pending entry. Only errors from the queue itself
+// (or ctx) are returned, posting failures are recorded on the entries.
+func (w *QueueWorker) Drain(ctx context.Context) error {
+	entries, err := w.queue.Pending()
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		postErr := w.post(ctx, entry)
+		switch {
+		case postErr == nil:
+			err = w.queue.Ack(entry.Id)
+		case ctx.Err() != nil:
+			// We gave up, that's not the entry's fault
+			return ctx.Err()
+		case !w.isTransient(entry, postErr) || entry.Attempts+1 >= w.config.MaxAttempts:
+			err = w.queue.DeadLetter(entry.Id, postErr)
+		default:
+			err = w.queue.Retry(entry.Id, postErr)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *QueueWorker) post(ctx context.Context, entry QueueEntry) error {
+	switch entry.Kind {
+	case QueueKindTrack:
+		var tr TrackRequest
+		if err := json.Unmarshal(entry.Payload, &tr); err != nil {
+			return &permanentError{err}
+		}
+		rt, err := tr.rawTrackRequest()
+		if err != nil {
+			return &permanentError{err}
+		}
+		if _, err := RawPostTrackRequestContext(ctx, w.client.transport, rt); err != nil {
+			return err
+		}
+
+		// Entries queued before deletes got their own entry still carry them
+		if len(tr.DeletePushTokenAttributes) > 0 {
+			return enqueue(w.queue, QueueKindPushTokenDelete, &RawPushTokenDeleteRequest{
+				AppGroupId: tr.AppGroupId,
+				PushTokens: tr.rawDeletePushTokens(),
+			})
+		}
+		return nil
+	case QueueKindPushTokenDelete:
+		var dr RawPushTokenDeleteRequest
+		if err := json.Unmarshal(entry.Payload, &dr); err != nil {
+			return &permanentError{err}
+		}
+		return RawPostDeletePushTokenRequestContext(ctx, w.client.transport, &dr)
+	case QueueKindCampaignTrigger:
+		var ctr CampaignTriggerRequest
+		if err := json.Unmarshal(entry.Payload, &ctr); err != nil {
+			return &permanentError{err}
+		}
+		ctr.transport = w.client.transport
+		return ctr.PostContext(ctx)
+	case QueueKindCanvasTrigger:
+		var ctr CanvasTriggerRequest
+		if err := json.Unmarshal(entry.Payload, &ctr); err != nil {
+			return &permanentError{err}
+		}
+		ctr.transport = w.client.transport
+		_, err := ctr.PostContext(ctx)
+		return err
+	default:
+		return &permanentError{fmt.Errorf("QueueWorker doesn't know how to post a %q entry", entry.Kind)}
+	}
+}
+
+// Whether trying again later could work. An entry that isn't safe to send
+// twice (a trigger, or a track request with events, purchases or increments)
+// may have gone through when app-boy answered with a 5xx or never answered,
+// so those are only retried after a rate limit.
+func (w *QueueWorker) isTransient(entry QueueEntry, err error) bool {
+	var perm *permanentError
+	if errors.As(err, &perm) {
+		return false
+	}
+	if IsRateLimited(err) {
+		return true
+	}
+	if isNonIdempotentEntry(entry) {
+		return false
+	}
+
+	var netErr *networkError
+	return IsRetryable(err) || errors.As(err, &netErr)
+}
+
+func isNonIdempotentEntry(entry QueueEntry) bool {
+	if entry.Kind != QueueKindTrack {
+		return isNonIdempotent(queueKindPaths[entry.Kind])
+	}
+
+	var tr TrackRequest
+	if err := json.Unmarshal(entry.Payload, &tr); err != nil {
+		return true
+	}
+	rt, err := tr.rawTrackRequest()
+	return err != nil || rt.isNonIdempotent()
+}
+
+var queueKindPaths = map[string]string{
+	QueueKindTrack:           TrackPath,
+	QueueKindCampaignTrigger: CampaignTriggerPath,
+	QueueKindCanvasTrigger:   CanvasTriggerPath,
+	QueueKindPushTokenDelete: DeletePushTokenPath,
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
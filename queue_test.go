@@ -0,0 +1,398 @@
+package gogo_boy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestQueue(t *testing.T) {
+	var dir string
+	before := func() {
+		var err error
+		dir, err = ioutil.TempDir("", "gogo-boy-queue")
+		checkErr(err)
+	}
+
+	after := func() {
+		os.RemoveAll(dir)
+	}
+
+	open := func() *FileQueue {
+		q, err := NewFileQueue(filepath.Join(dir, "queue.log"))
+		checkErr(err)
+		return q
+	}
+
+	Convey("Entries survive reopening the queue", t, func() {
+		before()
+		defer after()
+
+		q := open()
+		tr := NewClient("foo").NewAppClient("blah").NewTrackRequest("holah")
+		tr.SetFirstName("foo")
+		checkErr(EnqueueTrackRequest(q, tr))
+		ctr := NewClient("foo").NewCampaignTriggerRequest("my-campaign-id")
+		ctr.AddRecipient("holah", nil)
+		checkErr(EnqueueCampaignTriggerRequest(q, ctr))
+		checkErr(EnqueueTrackRequest(q, tr))
+
+		pending, err := q.Pending()
+		checkErr(err)
+		checkErr(q.Ack(pending[0].Id))
+		checkErr(q.Retry(pending[1].Id, errors.New("try again")))
+		checkErr(q.Close())
+
+		q = open()
+		defer q.Close()
+		pending, err = q.Pending()
+		checkErr(err)
+		So(len(pending), ShouldEqual, 2)
+		So(pending[0].Kind, ShouldEqual, QueueKindCampaignTrigger)
+		So(pending[0].Attempts, ShouldEqual, 1)
+		So(pending[0].LastError, ShouldEqual, "try again")
+		So(pending[1].Kind, ShouldEqual, QueueKindTrack)
+
+		// Ids keep counting up after a restart
+		entry, err := q.Push(QueueEntry{Kind: QueueKindTrack})
+		checkErr(err)
+		So(entry.Id, ShouldBeGreaterThan, pending[1].Id)
+	})
+
+	Convey("Takes nil errors", t, func() {
+		before()
+		defer after()
+
+		q := open()
+		defer q.Close()
+		first, err := q.Push(QueueEntry{Kind: QueueKindTrack})
+		checkErr(err)
+		second, err := q.Push(QueueEntry{Kind: QueueKindTrack})
+		checkErr(err)
+
+		checkErr(q.Retry(first.Id, nil))
+		checkErr(q.DeadLetter(second.Id, nil))
+		pending, _ := q.Pending()
+		So(len(pending), ShouldEqual, 1)
+		So(pending[0].Attempts, ShouldEqual, 1)
+		So(pending[0].LastError, ShouldEqual, "")
+		dead, _ := q.DeadLetters()
+		So(dead[0].LastError, ShouldEqual, "")
+	})
+
+	Convey("Ignores a record torn by a crash", t, func() {
+		before()
+		defer after()
+
+		q := open()
+		checkErr(EnqueueTrackRequest(q, NewClient("foo").NewAppClient("blah").NewTrackRequest("holah")))
+		checkErr(q.Close())
+
+		f, err := os.OpenFile(filepath.Join(dir, "queue.log"), os.O_WRONLY|os.O_APPEND, 0644)
+		checkErr(err)
+		f.WriteString(`{"op":"push","id":2,"entry":{"id":2,"ki`)
+		f.Close()
+
+		q = open()
+		defer q.Close()
+		pending, err := q.Pending()
+		checkErr(err)
+		So(len(pending), ShouldEqual, 1)
+	})
+
+	Convey("Compacts the log when reopened", t, func() {
+		before()
+		defer after()
+
+		q := open()
+		for i := 0; i < 10; i++ {
+			entry, err := q.Push(QueueEntry{Kind: QueueKindTrack})
+			checkErr(err)
+			checkErr(q.Ack(entry.Id))
+		}
+		checkErr(q.Close())
+
+		q = open()
+		defer q.Close()
+		info, err := os.Stat(filepath.Join(dir, "queue.log"))
+		checkErr(err)
+		So(info.Size(), ShouldEqual, 0)
+	})
+
+	Convey("Keeps working when compacting fails", t, func() {
+		before()
+		defer after()
+
+		q := open()
+		defer q.Close()
+		first, err := q.Push(QueueEntry{Kind: QueueKindTrack})
+		checkErr(err)
+
+		// Nothing can be written where the new log would go
+		tmpPath := filepath.Join(dir, "queue.log.tmp")
+		checkErr(os.Mkdir(tmpPath, 0755))
+		q.appended = fileQueueCompactAfter + 1
+		second, err := q.Push(QueueEntry{Kind: QueueKindTrack})
+		checkErr(err)
+		checkErr(q.Ack(first.Id))
+		So(q.appended, ShouldBeGreaterThan, fileQueueCompactAfter)
+
+		// Compacts on the next change once it can
+		checkErr(os.Remove(tmpPath))
+		checkErr(q.Retry(second.Id, errors.New("try again")))
+		So(q.appended, ShouldEqual, 0)
+		third, err := q.Push(QueueEntry{Kind: QueueKindTrack})
+		checkErr(err)
+		checkErr(q.Close())
+
+		q = open()
+		defer q.Close()
+		pending, _ := q.Pending()
+		So(len(pending), ShouldEqual, 2)
+		So(pending[0].Id, ShouldEqual, second.Id)
+		So(pending[0].LastError, ShouldEqual, "try again")
+		So(pending[1].Id, ShouldEqual, third.Id)
+	})
+
+	Convey("The worker posts, retries and dead letters entries", t, func() {
+		before()
+		defer after()
+
+		// Each user id gets its own status code
+		statusCodes := map[string]int{"ok": 201, "invalid": 400, "flaky": 503}
+		var calls int
+		rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			calls++
+			var request RawTrackRequest
+			checkErr(json.NewDecoder(req.Body).Decode(&request))
+			return newStubResponse(statusCodes[request.Attributes[0].ExternalId], `{"message":"whatever"}`), nil
+		})
+		client := NewClient("foo", WithRoundTripper(rt))
+		appClient := client.NewAppClient("blah")
+
+		q := open()
+		defer q.Close()
+		for _, id := range []string{"ok", "invalid", "flaky"} {
+			checkErr(EnqueueTrackRequest(q, appClient.NewTrackRequest(id)))
+		}
+
+		worker := client.NewQueueWorker(q, QueueWorkerConfig{MaxAttempts: 2})
+		checkErr(worker.Drain(context.Background()))
+		So(calls, ShouldEqual, 3)
+
+		// Only the flaky one is still around
+		pending, _ := q.Pending()
+		So(len(pending), ShouldEqual, 1)
+		So(pending[0].Attempts, ShouldEqual, 1)
+
+		dead, err := q.DeadLetters()
+		checkErr(err)
+		So(len(dead), ShouldEqual, 1)
+		So(string(dead[0].Payload), ShouldContainSubstring, `"ExternalId":"invalid"`)
+		So(dead[0].LastError, ShouldContainSubstring, "400")
+
+		// Second strike for the flaky one
+		checkErr(worker.Drain(context.Background()))
+		pending, _ = q.Pending()
+		So(len(pending), ShouldEqual, 0)
+		dead, _ = q.DeadLetters()
+		So(len(dead), ShouldEqual, 2)
+	})
+
+	Convey("Push token deletes are retried without sending the track request again", t, func() {
+		before()
+		defer after()
+
+		var tracks, deletes int
+		rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path == DeletePushTokenPath {
+				deletes++
+				if deletes == 1 {
+					return newStubResponse(500, `{"message":"whatever"}`), nil
+				}
+				return newStubResponse(201, `{"message":"success"}`), nil
+			}
+			tracks++
+			return newStubResponse(201, `{"message":"success"}`), nil
+		})
+		client := NewClient("foo", WithRoundTripper(rt))
+
+		q := open()
+		defer q.Close()
+		tr := client.NewAppClient("blah").NewTrackRequest("holah")
+		event := NewEvent()
+		event.SetName("logged_out")
+		tr.AddEvent(event)
+		tr.RemovePushToken("old-token")
+		checkErr(EnqueueTrackRequest(q, tr))
+
+		pending, _ := q.Pending()
+		So(len(pending), ShouldEqual, 2)
+		So(pending[0].Kind, ShouldEqual, QueueKindTrack)
+		So(string(pending[0].Payload), ShouldNotContainSubstring, "old-token")
+		So(pending[1].Kind, ShouldEqual, QueueKindPushTokenDelete)
+
+		worker := client.NewQueueWorker(q, QueueWorkerConfig{})
+		checkErr(worker.Drain(context.Background()))
+		checkErr(worker.Drain(context.Background()))
+		So(tracks, ShouldEqual, 1)
+		So(deletes, ShouldEqual, 2)
+		pending, _ = q.Pending()
+		So(len(pending), ShouldEqual, 0)
+	})
+
+	Convey("The worker only retries track requests that would record something twice after a rate limit", t, func() {
+		before()
+		defer after()
+
+		var calls int
+		statusCode := 503
+		rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			calls++
+			return newStubResponse(statusCode, `{"message":"whatever"}`), nil
+		})
+		client := NewClient("foo", WithRoundTripper(rt))
+		appClient := client.NewAppClient("blah")
+
+		q := open()
+		defer q.Close()
+		tr := appClient.NewTrackRequest("holah")
+		checkErr(tr.IncrementCustomAttribute("logins", 1))
+		checkErr(EnqueueTrackRequest(q, tr))
+		plain := appClient.NewTrackRequest("holah")
+		plain.SetFirstName("foo")
+		checkErr(EnqueueTrackRequest(q, plain))
+
+		worker := client.NewQueueWorker(q, QueueWorkerConfig{})
+		checkErr(worker.Drain(context.Background()))
+		So(calls, ShouldEqual, 2)
+		pending, _ := q.Pending()
+		So(len(pending), ShouldEqual, 1)
+		So(string(pending[0].Payload), ShouldNotContainSubstring, "logins")
+		dead, _ := q.DeadLetters()
+		So(len(dead), ShouldEqual, 1)
+		So(string(dead[0].Payload), ShouldContainSubstring, "logins")
+
+		// A rate limit means app-boy didn't take it
+		statusCode = 429
+		checkErr(EnqueueTrackRequest(q, tr))
+		checkErr(worker.Drain(context.Background()))
+		pending, _ = q.Pending()
+		So(len(pending), ShouldEqual, 2)
+	})
+
+	Convey("The worker doesn't retry campaign triggers that may have been sent", t, func() {
+		before()
+		defer after()
+
+		rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return nil, errors.New("connection reset by peer")
+		})
+		client := NewClient("foo", WithRoundTripper(rt))
+
+		q := open()
+		defer q.Close()
+		ctr := client.NewCampaignTriggerRequest("my-campaign-id")
+		ctr.AddRecipient("holah", nil)
+		checkErr(EnqueueCampaignTriggerRequest(q, ctr))
+		checkErr(EnqueueTrackRequest(q, client.NewAppClient("blah").NewTrackRequest("holah")))
+
+		checkErr(client.NewQueueWorker(q, QueueWorkerConfig{}).Drain(context.Background()))
+
+		pending, _ := q.Pending()
+		So(len(pending), ShouldEqual, 1)
+		So(pending[0].Kind, ShouldEqual, QueueKindTrack)
+		dead, _ := q.DeadLetters()
+		So(len(dead), ShouldEqual, 1)
+		So(dead[0].Kind, ShouldEqual, QueueKindCampaignTrigger)
+	})
+
+	Convey("Triggers the worker couldn't post in one request aren't enqueued", t, func() {
+		before()
+		defer after()
+
+		q := open()
+		defer q.Close()
+		client := NewClient("foo")
+		ctr := client.NewCampaignTriggerRequest("my-campaign-id")
+		canvas := client.NewCanvasTriggerRequest("my-canvas-id")
+		for i := 0; i <= MaxCampaignTriggerRecipients; i++ {
+			ctr.AddRecipient(fmt.Sprintf("user-%d", i), nil)
+			canvas.AddRecipient(fmt.Sprintf("user-%d", i), nil)
+		}
+
+		So(EnqueueCampaignTriggerRequest(q, ctr), ShouldNotEqual, nil)
+		So(EnqueueCanvasTriggerRequest(q, canvas), ShouldNotEqual, nil)
+		pending, _ := q.Pending()
+		So(len(pending), ShouldEqual, 0)
+	})
+
+	Convey("Requests Post would refuse aren't enqueued", t, func() {
+		before()
+		defer after()
+
+		q := open()
+		defer q.Close()
+		client := NewClient("foo")
+
+		// No recipients and no broadcast
+		So(EnqueueCampaignTriggerRequest(q, client.NewCampaignTriggerRequest("my-campaign-id")), ShouldNotEqual, nil)
+		So(EnqueueCanvasTriggerRequest(q, client.NewCanvasTriggerRequest("my-canvas-id")), ShouldNotEqual, nil)
+
+		ctr := client.NewCampaignTriggerRequest("my-campaign-id")
+		ctr.Broadcast = true
+		ctr.SendId = strings.Repeat("x", MaxSendIdLength+1)
+		So(EnqueueCampaignTriggerRequest(q, ctr), ShouldNotEqual, nil)
+
+		So(EnqueueTrackRequest(q, client.NewAppClient("blah").NewTrackRequest("")), ShouldNotEqual, nil)
+
+		pending, _ := q.Pending()
+		So(len(pending), ShouldEqual, 0)
+	})
+
+	Convey("The worker posts canvas triggers", t, func() {
+		before()
+		defer after()
+
+		var request RawCanvasTriggerRequest
+		rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			checkErr(json.NewDecoder(req.Body).Decode(&request))
+			return newStubResponse(201, `{"dispatch_id":"dispatch-1","message":"success"}`), nil
+		})
+		client := NewClient("foo", WithRoundTripper(rt))
+
+		q := open()
+		defer q.Close()
+		ctr := client.NewCanvasTriggerRequest("my-canvas-id")
+		ctr.AddRecipient("holah", map[string]interface{}{"plan": "pro"})
+		checkErr(EnqueueCanvasTriggerRequest(q, ctr))
+
+		checkErr(client.NewQueueWorker(q, QueueWorkerConfig{}).Drain(context.Background()))
+		So(request.CanvasId, ShouldEqual, "my-canvas-id")
+		So(request.Recipients[0].CanvasEntryProperties, ShouldResemble, map[string]interface{}{"plan": "pro"})
+		pending, _ := q.Pending()
+		So(len(pending), ShouldEqual, 0)
+	})
+
+	Convey("Run stops when the context is done", t, func() {
+		before()
+		defer after()
+
+		q := open()
+		defer q.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := NewClient("foo").NewQueueWorker(q, QueueWorkerConfig{}).Run(ctx)
+		So(errors.Is(err, context.Canceled), ShouldEqual, true)
+	})
+}
@@ -0,0 +1,209 @@
+package gogo_boy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+/*
+	----------------------------------------------------------------------
+	Client side rate limiting
+	----------------------------------------------------------------------
+*/
+
+// Returned (wrapped) by non-blocking limiters when an endpoint's budget is
+// used up. IsRateLimited reports true for it.
+var ErrRateLimitExceeded = errors.New("client side rate limit exceeded")
+
+// Allow Requests per Per on one endpoint, with bursts of up to Burst
+// (defaults to Requests)
+type RateLimit struct {
+	Requests int
+	Per      time.Duration
+	Burst    int
+
+	// Wait for budget instead of failing with ErrRateLimitExceeded
+	Block bool
+}
+
+// App-boy's documented defaults at the time of writing, your contract may
+// allow more. Limiters built from these block until there's budget. Limits
+// app-boy applies per campaign (like creating send ids) aren't here since one
+// bucket for the path would hold every campaign to a single campaign's budget.
+func DefaultRateLimits() map[string]RateLimit {
+	return map[string]RateLimit{
+		TrackPath:                 {Requests: 3000, Per: 3 * time.Second, Block: true},
+		DeletePushTokenPath:       {Requests: 250000, Per: time.Hour, Block: true},
+		CampaignTriggerPath:       {Requests: 250000, Per: time.Hour, Block: true},
+		CanvasTriggerPath:         {Requests: 250000, Per: time.Hour, Block: true},
+		MessagesSendPath:          {Requests: 250000, Per: time.Hour, Block: true},
+		UsersDeletePath:           {Requests: 20000, Per: time.Minute, Block: true},
+		UsersIdentifyPath:         {Requests: 20000, Per: time.Minute, Block: true},
+		NewAliasPath:              {Requests: 20000, Per: time.Minute, Block: true},
+		UpdateAliasPath:           {Requests: 20000, Per: time.Minute, Block: true},
+		UsersMergePath:            {Requests: 20, Per: time.Minute, Block: true},
+		ExternalIdsRenamePath:     {Requests: 1000, Per: time.Minute, Block: true},
+		ExternalIdsRemovePath:     {Requests: 1000, Per: time.Minute, Block: true},
+		SubscriptionStatusSetPath: {Requests: 5000, Per: time.Minute, Block: true},
+	}
+}
+
+// Limit requests to path (e.g. TrackPath) on this client
+func WithRateLimit(path string, limit RateLimit) ClientOption {
+	return func(t *Transport) {
+		if t.limiters == nil {
+			t.limiters = map[string]*tokenBucket{}
+		}
+		t.limiters[path] = newTokenBucket(limit, time.Now())
+	}
+}
+
+func WithRateLimits(limits map[string]RateLimit) ClientOption {
+	return func(t *Transport) {
+		for path, limit := range limits {
+			WithRateLimit(path, limit)(t)
+		}
+	}
+}
+
+// Waits for (or fails without) budget on path, endpoints without a limit
+// always go through
+func (t *Transport) takeRateLimit(ctx context.Context, path string) error {
+	if b := t.limiters[path]; b != nil {
+		return b.take(ctx)
+	}
+
+	return nil
+}
+
+// Lets app-boy's X-RateLimit-* headers correct our local estimate
+func (t *Transport) observeRateLimit(path string, header http.Header) {
+	if b := t.limiters[path]; b != nil {
+		b.observe(header, time.Now())
+	}
+}
+
+type tokenBucket struct {
+	mu sync.Mutex
+
+	limit       RateLimit
+	rate        float64 // Tokens per second
+	burst       float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time // Set when app-boy tells us we're out of budget
+}
+
+func newTokenBucket(limit RateLimit, now time.Time) *tokenBucket {
+	burst := limit.Burst
+	if burst <= 0 {
+		burst = limit.Requests
+	}
+
+	b := &tokenBucket{
+		limit:  limit,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   now,
+	}
+	if limit.Per > 0 {
+		b.rate = float64(limit.Requests) / limit.Per.Seconds()
+	}
+
+	return b
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	start := b.last
+	if b.pausedUntil.After(start) {
+		start = b.pausedUntil
+	}
+
+	if now.After(start) {
+		b.tokens += now.Sub(start).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	if now.After(b.last) {
+		b.last = now
+	}
+}
+
+// Takes a token if there is one, otherwise returns how long until there is
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+	if b.tokens >= 1 && !now.Before(b.pausedUntil) {
+		b.tokens--
+		return 0
+	}
+
+	var wait time.Duration
+	if now.Before(b.pausedUntil) {
+		wait = b.pausedUntil.Sub(now)
+	}
+	if b.tokens < 1 && b.rate > 0 {
+		wait += time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	}
+	if wait <= 0 {
+		// A zero rate never refills, don't spin
+		wait = time.Second
+	}
+
+	return wait
+}
+
+func (b *tokenBucket) take(ctx context.Context) error {
+	if b.limit.Requests <= 0 {
+		return nil
+	}
+
+	for {
+		wait := b.reserve(time.Now())
+		if wait == 0 {
+			return nil
+		}
+		if !b.limit.Block {
+			return ErrRateLimitExceeded
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (b *tokenBucket) observe(header http.Header, now time.Time) {
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+	if float64(remaining) < b.tokens {
+		b.tokens = float64(remaining)
+	}
+
+	// Out of budget on app-boy's side, hold off until it resets
+	if remaining <= 0 {
+		if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			if at := time.Unix(reset, 0); at.After(b.pausedUntil) {
+				b.pausedUntil = at
+			}
+		}
+	}
+}
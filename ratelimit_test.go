@@ -0,0 +1,108 @@
+package gogo_boy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRateLimit(t *testing.T) {
+	ok := func(calls *int) roundTripperFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			*calls++
+			return newStubResponse(201, `{"message":"success"}`), nil
+		}
+	}
+
+	Convey("Fails once a non-blocking budget is used up", t, func() {
+		var calls int
+		transport := NewTransport(WithRoundTripper(ok(&calls)), WithRateLimit(TrackPath, RateLimit{Requests: 2, Per: time.Hour}))
+
+		for i := 0; i < 2; i++ {
+			_, err := RawPostTrackRequestWithTransport(transport, &RawTrackRequest{})
+			So(err, ShouldEqual, nil)
+		}
+
+		_, err := RawPostTrackRequestWithTransport(transport, &RawTrackRequest{})
+		So(errors.Is(err, ErrRateLimitExceeded), ShouldEqual, true)
+		So(IsRateLimited(err), ShouldEqual, true)
+		So(calls, ShouldEqual, 2)
+
+		// Other endpoints have their own budget
+		err = RawPostDeletePushTokenRequestWithTransport(transport, &RawPushTokenDeleteRequest{})
+		So(err, ShouldEqual, nil)
+	})
+
+	Convey("Blocks until there's budget", t, func() {
+		var calls int
+		transport := NewTransport(WithRoundTripper(ok(&calls)), WithRateLimits(map[string]RateLimit{
+			CampaignTriggerPath: {Requests: 1, Per: 20 * time.Millisecond, Block: true},
+		}))
+
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			err := RawPostCampaignTriggerRequestWithTransport(transport, &RawCampaignTriggerRequest{})
+			So(err, ShouldEqual, nil)
+		}
+		So(time.Since(start), ShouldBeGreaterThanOrEqualTo, 30*time.Millisecond)
+		So(calls, ShouldEqual, 3)
+	})
+
+	Convey("Blocking respects the context", t, func() {
+		var calls int
+		transport := NewTransport(WithRoundTripper(ok(&calls)), WithRateLimit(TrackPath, RateLimit{Requests: 1, Per: time.Hour, Block: true}))
+
+		_, err := RawPostTrackRequestWithTransport(transport, &RawTrackRequest{})
+		So(err, ShouldEqual, nil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err = RawPostTrackRequestContext(ctx, transport, &RawTrackRequest{})
+		So(errors.Is(err, context.DeadlineExceeded), ShouldEqual, true)
+		So(calls, ShouldEqual, 1)
+	})
+
+	Convey("Adapts to X-RateLimit-Remaining", t, func() {
+		var calls int
+		rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			calls++
+			resp := newStubResponse(201, `{"message":"success"}`)
+			resp.Header.Set("X-RateLimit-Remaining", "0")
+			resp.Header.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+			return resp, nil
+		})
+
+		transport := NewTransport(WithRoundTripper(rt), WithRateLimit(TrackPath, RateLimit{Requests: 100, Per: time.Second}))
+		_, err := RawPostTrackRequestWithTransport(transport, &RawTrackRequest{})
+		So(err, ShouldEqual, nil)
+
+		// We had plenty of local budget but app-boy says we're out
+		_, err = RawPostTrackRequestWithTransport(transport, &RawTrackRequest{})
+		So(errors.Is(err, ErrRateLimitExceeded), ShouldEqual, true)
+		So(calls, ShouldEqual, 1)
+	})
+
+	Convey("Token buckets refill over time", t, func() {
+		now := time.Unix(0, 0)
+		b := newTokenBucket(RateLimit{Requests: 10, Per: 10 * time.Second, Burst: 2}, now)
+
+		So(b.reserve(now), ShouldEqual, 0)
+		So(b.reserve(now), ShouldEqual, 0)
+		So(b.reserve(now), ShouldEqual, time.Second)
+		So(b.reserve(now.Add(time.Second)), ShouldEqual, 0)
+
+		// Never above the burst
+		So(b.reserve(now.Add(time.Hour)), ShouldEqual, 0)
+		So(b.reserve(now.Add(time.Hour)), ShouldEqual, 0)
+		So(b.reserve(now.Add(time.Hour)), ShouldBeGreaterThan, 0)
+
+		b.observe(http.Header{"X-Ratelimit-Remaining": []string{"0"}, "X-Ratelimit-Reset": []string{"7200"}}, now.Add(time.Hour))
+		So(b.reserve(now.Add(time.Hour)), ShouldEqual, time.Hour+time.Second)
+		So(b.reserve(now.Add(2*time.Hour+time.Second)), ShouldEqual, 0)
+	})
+}
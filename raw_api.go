@@ -2,10 +2,9 @@ package gogo_boy
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 	"time"
 )
 
@@ -16,57 +15,139 @@ import (
 */
 
 const (
-	TrackEndpoint           = "https://api.appboy.com/users/track"
-	DeletePushTokenEndpoint = "https://api.appboy.com/push_notification/remove"
-	CampaignTriggerEndpoint = "https://api.appboy.com/campaigns/trigger/send"
+	TrackPath           = "/users/track"
+	DeletePushTokenPath = "/push_notification/remove"
+	CampaignTriggerPath = "/campaigns/trigger/send"
+
+	// Full URLs on the default host
+	TrackEndpoint           = DefaultBaseURL + TrackPath
+	DeletePushTokenEndpoint = DefaultBaseURL + DeletePushTokenPath
+	CampaignTriggerEndpoint = DefaultBaseURL + CampaignTriggerPath
 
 	timeoutDuration = time.Second * time.Duration(5)
 )
 
+// Every raw request carries the app group id in the body, which is how the
+// legacy API authenticates. It's omitted when empty so clients using an API key
+// (see WithAPIKey) don't need one.
+
 // A track request is used to track purchases, user events, etc. It's configured
 // so you can batch requests which won't count against your API limit.
 type RawTrackRequest struct {
-	AppGroupId string              `json:"app_group_id"`
+	AppGroupId string              `json:"app_group_id,omitempty"`
 	Attributes []RawAttributesInfo `json:"attributes,omitempty"` // Attributes are per-user information
 	Purchases  []RawPurchaseInfo   `json:"purchases,omitempty"`  // Purchases are special events, each bound to a user
 	Events     []RawEventInfo      `json:"events,omitempty"`     // Events
 }
 
+// Events, purchases and increments are recorded again when the same request
+// is sent twice, setting attributes isn't
+func (rt *RawTrackRequest) isNonIdempotent() bool {
+	if len(rt.Events) > 0 || len(rt.Purchases) > 0 {
+		return true
+	}
+
+	for _, attributes := range rt.Attributes {
+		for _, value := range attributes.CustomAttributes {
+			if op, ok := value.(map[string]interface{}); ok && op["inc"] != nil {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// What app-boy tells us about a track request it accepted. Objects it
+// couldn't process are listed in Errors even though the request succeeded,
+// so check HasErrors if you care about silently dropped data.
+type TrackResponse struct {
+	AttributesProcessed int             `json:"attributes_processed,omitempty"`
+	EventsProcessed     int             `json:"events_processed,omitempty"`
+	PurchasesProcessed  int             `json:"purchases_processed,omitempty"`
+	Message             string          `json:"message"`
+	Errors              []ResponseError `json:"errors,omitempty"`
+}
+
+func (r *TrackResponse) HasErrors() bool {
+	return len(r.Errors) > 0
+}
+
 // App boy seperates deletion of push tokens into a seperate endpoint that's different
 // than track request.  Why?  no idea.
 type RawPushTokenDeleteRequest struct {
-	AppGroupId string             `json:"app_group_id"`
+	AppGroupId string             `json:"app_group_id,omitempty"`
 	PushTokens []RawPushTokenInfo `json:"push_tokens"`
 }
 
-// Trigger a campaign
+// App-boy won't trigger a campaign for more recipients than this per request
+const MaxCampaignTriggerRecipients = 50
+
+// Longest send_id app-boy accepts
+const MaxSendIdLength = 64
+
+// Trigger a campaign. Either list Recipients or set Broadcast to send to
+// everyone in the campaign's segment (narrowed down by Audience if set).
 type RawCampaignTriggerRequest struct {
-	Recipients []RawCampaignRecipient `json:"recipients"`
+	Recipients []RawCampaignRecipient `json:"recipients,omitempty"`
 	CampaignId string                 `json:"campaign_id"`
-	AppGroupId string                 `json:"app_group_id"`
+	AppGroupId string                 `json:"app_group_id,omitempty"`
+
+	SendId            string                 `json:"send_id,omitempty"` // Tracks this send's analytics separately, see CreateSendId
+	Broadcast         bool                   `json:"broadcast,omitempty"`
+	Audience          Audience               `json:"audience,omitempty"`
+	TriggerProperties map[string]interface{} `json:"trigger_properties,omitempty"` // For every recipient
 }
 
 // A campaign trigger has many user recipients
 type RawCampaignRecipient struct {
-	ExternalId        string                 `json:"external_user_id"`
+	ExternalId        string                 `json:"external_user_id,omitempty"`
+	UserAlias         *UserAlias             `json:"user_alias,omitempty"`
+	Email             string                 `json:"email,omitempty"`
 	TriggerProperties map[string]interface{} `json:"trigger_properties"`
 }
 
 type RawAttributesInfo struct {
-	ExternalId string `json:"external_id"` // The id of your user in your database
+	// Set one of these (or Email below) to say who the user is
+	ExternalId string     `json:"external_id,omitempty"` // The id of your user in your database
+	UserAlias  *UserAlias `json:"user_alias,omitempty"`
+	BrazeId    string     `json:"braze_id,omitempty"`
+
+	// Must be false to create a user that only has an alias
+	UpdateExistingOnly *bool `json:"_update_existing_only,omitempty"`
 
 	//PushTokenImport bool               `json:"push_token_import,omitempty"` // Are you importing a push token?
 	PushTokens []RawPushTokenInfo `json:"push_tokens,omitempty"` // A list of push tokens
 
-	FirstName string `json:"first_name,omitempty"` // User's first name
-	LastName  string `json:"last_name,omitempty"`  // User's last name
-	Email     string `json:"email,omitempty"`      // User's email
+	FirstName       string       `json:"first_name,omitempty"` // User's first name
+	LastName        string       `json:"last_name,omitempty"`  // User's last name
+	Email           string       `json:"email,omitempty"`      // User's email
+	DateOfBirth     string       `json:"dob,omitempty"`        // YYYY-MM-DD
+	Gender          string       `json:"gender,omitempty"`     // One of the Gender constants
+	Country         string       `json:"country,omitempty"`    // ISO-3166-1 alpha-2
+	CurrentLocation *RawLocation `json:"current_location,omitempty"`
+	HomeCity        string       `json:"home_city,omitempty"`
+	Language        string       `json:"language,omitempty"` // ISO-639-1
+	Phone           string       `json:"phone,omitempty"`
+	TimeZone        string       `json:"time_zone,omitempty"`       // IANA name, e.g. America/New_York
+	EmailSubscribe  string       `json:"email_subscribe,omitempty"` // One of the SubscriptionState constants
+	PushSubscribe   string       `json:"push_subscribe,omitempty"`  // One of the SubscriptionState constants
+	ImageURL        string       `json:"image_url,omitempty"`       // Avatar
+
+	// Merge nested object custom attributes into what's there instead of
+	// replacing them
+	MergeObjects bool `json:"_merge_objects,omitempty"`
 
 	// These don't really exist, we have to dynamically place them in the JSON
 	// at a later point
 	CustomAttributes map[string]interface{} `json:"-"`
 }
 
+type RawLocation struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
 // You may upload a push token via the API but most people
 type RawPushTokenInfo struct {
 	AppId string `json:"app_id"`
@@ -74,37 +155,87 @@ type RawPushTokenInfo struct {
 }
 
 type RawPurchaseInfo struct {
-	ExternalId string  `json:"external_id"`
-	ProductId  string  `json:"product_id"`
-	Currency   string  `json:"currency"`
-	Price      float32 `json:"price"`
-	Quantity   int     `json:"quantity"`
-	Time       string  `json:"time"`
+	ExternalId string      `json:"external_id,omitempty"`
+	UserAlias  *UserAlias  `json:"user_alias,omitempty"`
+	BrazeId    string      `json:"braze_id,omitempty"`
+	Email      string      `json:"email,omitempty"`
+	ProductId  string      `json:"product_id"`
+	Currency   string      `json:"currency"`
+	Price      json.Number `json:"price"`              // A decimal, see SetPriceDecimal
+	Quantity   int         `json:"quantity,omitempty"` // App-boy assumes 1 when left out
+	Time       string      `json:"time"`
+	Properties Properties  `json:"properties,omitempty"`
 }
 
 type RawEventInfo struct {
-	ExternalId string `json:"external_id"`
+	ExternalId string     `json:"external_id,omitempty"`
+	UserAlias  *UserAlias `json:"user_alias,omitempty"`
+	BrazeId    string     `json:"braze_id,omitempty"`
+	Email      string     `json:"email,omitempty"`
 	//AppId      string `json:"app_id,omitempty"`
-	Name               string `json:"name"`
-	Time               string `json:"time"` // Time is in ISO 8601 format
-	UpdateExistingOnly bool   `json:"_update_existing_only,omitempty"`
-
-	// This library doesn't support these (yet)
-	//Properties         string `json:"properties"`
+	Name               string     `json:"name"`
+	Time               string     `json:"time"` // Time is in ISO 8601 format
+	UpdateExistingOnly bool       `json:"_update_existing_only,omitempty"`
+	Properties         Properties `json:"properties,omitempty"`
 }
 
 // Post to track request endpoint
-func RawPostTrackRequest(trackRequest *RawTrackRequest) error {
+func RawPostTrackRequest(trackRequest *RawTrackRequest) (*TrackResponse, error) {
+	return RawPostTrackRequestWithTransport(nil, trackRequest)
+}
+
+// Same as RawPostTrackRequest but sent over your own transport
+func RawPostTrackRequestWithTransport(transport *Transport, trackRequest *RawTrackRequest) (*TrackResponse, error) {
+	return RawPostTrackRequestContext(context.Background(), transport, trackRequest)
+}
+
+// Same as RawPostTrackRequestWithTransport but aborts when ctx is done. A nil
+// transport uses the defaults.
+func RawPostTrackRequestContext(ctx context.Context, transport *Transport, trackRequest *RawTrackRequest) (*TrackResponse, error) {
+	json, err := marshalTrackRequest(trackRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	post := transportOrDefault(transport).post
+	if trackRequest.isNonIdempotent() {
+		post = transportOrDefault(transport).postNonIdempotent
+	}
+	body, err := post(ctx, "PostTrackRequest", TrackPath, json)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseTrackResponse(body)
+}
+
+func parseTrackResponse(body []byte) (*TrackResponse, error) {
+	res := &TrackResponse{}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return res, nil
+	}
+
+	if err := json.Unmarshal(body, res); err != nil {
+		return nil, fmt.Errorf("PostTrackRequest failed to parse the response '%s': %s", body, err)
+	}
+
+	return res, nil
+}
+
+func marshalTrackRequest(trackRequest *RawTrackRequest) ([]byte, error) {
 	// Marshal into a JSON string
 	__json, err := json.Marshal(trackRequest)
 	if err != nil {
-		return fmt.Errorf("PostTrackRequest failed: %s", err)
+		return nil, fmt.Errorf("PostTrackRequest failed: %s", err)
 	}
 
-	// Then un-marshal because we need to place our custom attributes
+	// Then un-marshal because we need to place our custom attributes, keeping
+	// numbers (like prices) exactly as they were written
 	var _json map[string]interface{}
-	if err := json.Unmarshal([]byte(__json), &_json); err != nil {
-		return fmt.Errorf("PostTrackRequest failed to unmarshal _json: %s", err)
+	decoder := json.NewDecoder(bytes.NewReader(__json))
+	decoder.UseNumber()
+	if err := decoder.Decode(&_json); err != nil {
+		return nil, fmt.Errorf("PostTrackRequest failed to unmarshal _json: %s", err)
 	}
 
 	// For each user attribute, find custom attributes
@@ -121,141 +252,49 @@ func RawPostTrackRequest(trackRequest *RawTrackRequest) error {
 
 	json, err := json.Marshal(_json)
 	if err != nil {
-		return fmt.Errorf("PostTrackRequest failed to add custom attributes: %s", err)
-	}
-
-	// Create post request and make sure you set the content type
-	req, err := http.NewRequest("POST", TrackEndpoint, bytes.NewBuffer(json))
-	if err != nil {
-		return fmt.Errorf("PostTrackRequest failed: %s", err)
-	}
-	if err != nil {
-		return fmt.Errorf("PostTrackRequest failed: %s", err)
-	}
-	req.Header.Add("Content-Type", "application/json")
-
-	// Our HTTP client
-	client := &http.Client{
-		Timeout: timeoutDuration,
-	}
-
-	// Execute request
-	var resp *http.Response
-	resp, err = client.Do(req)
-	if err != nil {
-		return fmt.Errorf("PostTrackRequest failed: %s", err)
+		return nil, fmt.Errorf("PostTrackRequest failed to add custom attributes: %s", err)
 	}
 
-	// Read body
-	var body []byte
-	body, err = ioutil.ReadAll(resp.Body)
-	resp.Body.Close()
-	if err != nil {
-		return fmt.Errorf("PostTrackRequest failed: %s", err)
-	}
-
-	//fmt.Printf("%v", string(json))
-	//fmt.Printf("%v", string(body))
-
-	// App-Boy returns a 201 if this is successful
-	if resp.StatusCode != 201 {
-		return fmt.Errorf("PostTrackRequest failed: Expected status code from app boy to be a 201 but we received a: %d with the payload: '%s'\n", resp.StatusCode, body)
-	}
-
-	return nil
+	return json, nil
 }
 
 // Post push token request endpoint
 func RawPostDeletePushTokenRequest(rawReq *RawPushTokenDeleteRequest) error {
-	jsonStr, err := json.Marshal(rawReq)
-	if err != nil {
-		return fmt.Errorf("RawPostDeletPushTokenRequest failed: %s", err)
-	}
-
-	// Create post request and make sure you set the content type
-	req, err := http.NewRequest("POST", DeletePushTokenEndpoint, bytes.NewBuffer(jsonStr))
-	if err != nil {
-		return fmt.Errorf("RawPostDeletPushTokenRequest failed: %s", err)
-	}
-	if err != nil {
-		return fmt.Errorf("RawPostDeletPushTokenRequest failed: %s", err)
-	}
-	req.Header.Add("Content-Type", "application/json")
-
-	// Our HTTP client
-	client := &http.Client{
-		Timeout: timeoutDuration,
-	}
+	return RawPostDeletePushTokenRequestWithTransport(nil, rawReq)
+}
 
-	// Execute request
-	var resp *http.Response
-	resp, err = client.Do(req)
-	if err != nil {
-		return fmt.Errorf("RawPostDeletPushTokenRequest failed: %s", err)
-	}
+// Same as RawPostDeletePushTokenRequest but sent over your own transport
+func RawPostDeletePushTokenRequestWithTransport(transport *Transport, rawReq *RawPushTokenDeleteRequest) error {
+	return RawPostDeletePushTokenRequestContext(context.Background(), transport, rawReq)
+}
 
-	// Read body
-	var body []byte
-	body, err = ioutil.ReadAll(resp.Body)
-	resp.Body.Close()
+// Same as RawPostDeletePushTokenRequestWithTransport but aborts when ctx is done
+func RawPostDeletePushTokenRequestContext(ctx context.Context, transport *Transport, rawReq *RawPushTokenDeleteRequest) error {
+	jsonStr, err := json.Marshal(rawReq)
 	if err != nil {
 		return fmt.Errorf("RawPostDeletPushTokenRequest failed: %s", err)
 	}
 
-	//fmt.Printf("%v", string(json))
-	//fmt.Printf("%v", string(body))
-
-	// App-Boy returns a 201 if this is successful
-	if resp.StatusCode != 201 {
-		return fmt.Errorf("RawPostDeletPushTokenRequest failed: Expected status code from app boy to be a 201 but we received a: %d with the payload: '%s'\n", resp.StatusCode, body)
-	}
-
-	return nil
+	_, err = transportOrDefault(transport).post(ctx, "RawPostDeletPushTokenRequest", DeletePushTokenPath, jsonStr)
+	return err
 }
 
 func RawPostCampaignTriggerRequest(rawReq *RawCampaignTriggerRequest) error {
-	jsonStr, err := json.Marshal(rawReq)
-	if err != nil {
-		return fmt.Errorf("RawCampaignTriggerRequest failed: %s", err)
-	}
-
-	// Create post request and make sure you set the content type
-	req, err := http.NewRequest("POST", CampaignTriggerEndpoint, bytes.NewBuffer(jsonStr))
-	if err != nil {
-		return fmt.Errorf("RawCampaignTriggerRequest failed: %s", err)
-	}
-	if err != nil {
-		return fmt.Errorf("RawCampaignTriggerRequest failed: %s", err)
-	}
-	req.Header.Add("Content-Type", "application/json")
-
-	// Our HTTP client
-	client := &http.Client{
-		Timeout: timeoutDuration,
-	}
+	return RawPostCampaignTriggerRequestWithTransport(nil, rawReq)
+}
 
-	// Execute request
-	var resp *http.Response
-	resp, err = client.Do(req)
-	if err != nil {
-		return fmt.Errorf("RawCampaignTriggerRequest failed: %s", err)
-	}
+// Same as RawPostCampaignTriggerRequest but sent over your own transport
+func RawPostCampaignTriggerRequestWithTransport(transport *Transport, rawReq *RawCampaignTriggerRequest) error {
+	return RawPostCampaignTriggerRequestContext(context.Background(), transport, rawReq)
+}
 
-	// Read body
-	var body []byte
-	body, err = ioutil.ReadAll(resp.Body)
-	resp.Body.Close()
+// Same as RawPostCampaignTriggerRequestWithTransport but aborts when ctx is done
+func RawPostCampaignTriggerRequestContext(ctx context.Context, transport *Transport, rawReq *RawCampaignTriggerRequest) error {
+	jsonStr, err := json.Marshal(rawReq)
 	if err != nil {
 		return fmt.Errorf("RawCampaignTriggerRequest failed: %s", err)
 	}
 
-	//fmt.Printf("%v", string(json))
-	//fmt.Printf("%v", string(body))
-
-	// App-Boy returns a 201 if this is successful
-	if resp.StatusCode != 201 {
-		return fmt.Errorf("RawCampaignTriggerRequest failed: Expected status code from app boy to be a 201 but we received a: %d with the payload: '%s'\n", resp.StatusCode, body)
-	}
-
-	return nil
+	_, err = transportOrDefault(transport).post(ctx, "RawCampaignTriggerRequest", CampaignTriggerPath, jsonStr)
+	return err
 }
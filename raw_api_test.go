@@ -1,6 +1,7 @@
 package gogo_boy
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"testing"
@@ -53,7 +54,7 @@ func TestRawAPI(t *testing.T) {
 					ExternalId: "foo",
 					ProductId:  "baz",
 					Currency:   "USD",
-					Price:      4.92,
+					Price:      "4.92",
 					Quantity:   1,
 					Time:       "Z070000",
 				},
@@ -67,9 +68,12 @@ func TestRawAPI(t *testing.T) {
 			},
 		}
 
-		err := RawPostTrackRequest(trackRequest)
+		res, err := RawPostTrackRequest(trackRequest)
 		checkErr(err)
 		So(err, ShouldEqual, nil)
+		So(res.AttributesProcessed, ShouldEqual, 1)
+		So(res.Message, ShouldEqual, "success")
+		So(res.HasErrors(), ShouldEqual, false)
 
 		// Root
 		So(request["app_group_id"], ShouldEqual, "foo")
@@ -127,7 +131,7 @@ func TestRawAPI(t *testing.T) {
 			},
 		}
 
-		err := RawPostTrackRequest(trackRequest)
+		_, err := RawPostTrackRequest(trackRequest)
 		checkErr(err)
 		So(err, ShouldEqual, nil)
 
@@ -194,7 +198,7 @@ func TestRawAPI(t *testing.T) {
 					ExternalId: "foo",
 					ProductId:  "baz",
 					Currency:   "USD",
-					Price:      4.92,
+					Price:      "4.92",
 					Quantity:   1,
 					Time:       "Z070000",
 				},
@@ -208,7 +212,7 @@ func TestRawAPI(t *testing.T) {
 			},
 		}
 
-		err := RawPostTrackRequest(trackRequest)
+		_, err := RawPostTrackRequest(trackRequest)
 		checkErr(err)
 		So(err, ShouldEqual, nil)
 
@@ -379,4 +383,43 @@ func TestRawAPI(t *testing.T) {
 		So(strings.Contains(errStr, "400"), ShouldEqual, true)
 	})
 
+	Convey("Raw requests respect context deadlines", t, func() {
+		before()
+		defer after()
+
+		var request map[string]interface{}
+		MockTrackSuccess(func(_request map[string]interface{}) {
+			request = _request
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 0)
+		defer cancel()
+
+		_, err := RawPostTrackRequestContext(ctx, nil, &RawTrackRequest{AppGroupId: "foo"})
+		So(err, ShouldNotEqual, nil)
+		So(request, ShouldBeNil)
+
+		err = RawPostDeletePushTokenRequestContext(ctx, nil, &RawPushTokenDeleteRequest{AppGroupId: "foo"})
+		So(err, ShouldNotEqual, nil)
+
+		err = RawPostCampaignTriggerRequestContext(ctx, nil, &RawCampaignTriggerRequest{AppGroupId: "foo"})
+		So(err, ShouldNotEqual, nil)
+	})
+
+	Convey("Reports objects dropped from a partially successful track request", t, func() {
+		before()
+		defer after()
+
+		httpmock.RegisterResponder("POST", TrackEndpoint,
+			httpmock.NewStringResponder(201, getFixtureWithPath("track_partial_success_res.json")))
+
+		res, err := RawPostTrackRequest(&RawTrackRequest{AppGroupId: "foo"})
+		So(err, ShouldEqual, nil)
+		So(res.AttributesProcessed, ShouldEqual, 1)
+		So(res.EventsProcessed, ShouldEqual, 2)
+		So(res.PurchasesProcessed, ShouldEqual, 0)
+		So(res.HasErrors(), ShouldEqual, true)
+		So(res.Errors[0].InputArray, ShouldEqual, "purchases")
+		So(res.Errors[0].Index, ShouldEqual, 0)
+	})
 }
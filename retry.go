@@ -0,0 +1,166 @@
+package gogo_boy
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+/*
+	----------------------------------------------------------------------
+	Retrying failed requests
+	----------------------------------------------------------------------
+*/
+
+// Decides whether and when a failed request is sent again. The zero value
+// never retries, which is also the default for a new Client.
+type RetryPolicy struct {
+	MaxAttempts int // Including the first attempt, anything below 2 disables retries
+
+	MinBackoff time.Duration // Wait before the first retry, doubled every attempt
+	MaxBackoff time.Duration // Upper bound for the doubling and app-boy's hints
+	Jitter     float64       // Fraction (0-1) of each wait that's randomised
+
+	// Which responses are worth retrying, nil means 429 and 5xx
+	RetryableStatusCodes []int
+
+	// Retry when app-boy couldn't be reached at all (DNS, resets, timeouts)
+	RetryNetworkErrors bool
+
+	// Campaign and Canvas triggers, message sends and transactional emails
+	// aren't idempotent, retrying one that app-boy received but didn't
+	// acknowledge will message your users twice. The same goes for track
+	// requests with events, purchases or increments, which would be recorded
+	// twice. Only turn this on if you can live with that.
+	RetryNonIdempotent bool
+}
+
+// A sensible policy for most setups, pass it to WithRetryPolicy. Requests
+// that aren't idempotent (see RetryNonIdempotent) still get a single attempt.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:        4,
+		MinBackoff:         500 * time.Millisecond,
+		MaxBackoff:         30 * time.Second,
+		Jitter:             0.2,
+		RetryNetworkErrors: true,
+	}
+}
+
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(t *Transport) {
+		t.RetryPolicy = policy
+	}
+}
+
+// Endpoints where sending the same payload twice does something twice
+var nonIdempotentPaths = map[string]bool{
+	CampaignTriggerPath: true,
+	CanvasTriggerPath:   true,
+	MessagesSendPath:    true,
+
+	// A second create would schedule a second send
+	CampaignScheduleCreatePath: true,
+	CanvasScheduleCreatePath:   true,
+	MessagesScheduleCreatePath: true,
+}
+
+// Transactional sends have the campaign id in their path so they can't go in
+// nonIdempotentPaths
+func isNonIdempotent(path string) bool {
+	return nonIdempotentPaths[path] || isTransactionalEmailPath(path)
+}
+
+// nonIdempotent is for payloads that aren't safe to repeat on paths that
+// usually are
+func (p RetryPolicy) attemptsFor(path string, nonIdempotent bool) int {
+	nonIdempotent = nonIdempotent || isNonIdempotent(path)
+	if p.MaxAttempts < 2 || (nonIdempotent && !p.RetryNonIdempotent) {
+		return 1
+	}
+
+	return p.MaxAttempts
+}
+
+func (p RetryPolicy) shouldRetry(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if p.RetryableStatusCodes == nil {
+			return apiErr.IsRetryable()
+		}
+
+		for _, code := range p.RetryableStatusCodes {
+			if code == apiErr.StatusCode {
+				return true
+			}
+		}
+		return false
+	}
+
+	var netErr *networkError
+	return p.RetryNetworkErrors && errors.As(err, &netErr)
+}
+
+// How long to wait before attempt+1. App-boy's own hints win over our backoff
+// but are still capped at MaxBackoff, a reset that's hours away would
+// otherwise hold the request up for hours.
+func (p RetryPolicy) backoff(attempt int, err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if wait, ok := retryAfter(apiErr.Header, time.Now()); ok {
+			if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+				wait = p.MaxBackoff
+			}
+			return wait
+		}
+	}
+
+	wait := p.MinBackoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || wait < p.MaxBackoff); i++ {
+		wait *= 2
+	}
+	if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+		wait = p.MaxBackoff
+	}
+
+	if p.Jitter > 0 {
+		wait -= time.Duration(float64(wait) * p.Jitter * rand.Float64())
+	}
+
+	return wait
+}
+
+// Reads Retry-After (seconds or an HTTP date) and falls back to
+// X-RateLimit-Reset (unix seconds)
+func retryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	if header == nil {
+		return 0, false
+	}
+
+	if v := header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil {
+			return nonNegative(time.Duration(secs) * time.Second), true
+		}
+		if at, err := http.ParseTime(v); err == nil {
+			return nonNegative(at.Sub(now)), true
+		}
+	}
+
+	if v := header.Get("X-RateLimit-Reset"); v != "" {
+		if unix, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return nonNegative(time.Unix(unix, 0).Sub(now)), true
+		}
+	}
+
+	return 0, false
+}
+
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+
+	return d
+}
@@ -0,0 +1,209 @@
+package gogo_boy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRetry(t *testing.T) {
+	fastPolicy := func() RetryPolicy {
+		policy := DefaultRetryPolicy()
+		policy.MinBackoff = time.Millisecond
+		policy.MaxBackoff = 5 * time.Millisecond
+		return policy
+	}
+
+	// Answers with each status in turn, then keeps repeating the last one
+	sequence := func(calls *int, statusCodes ...int) roundTripperFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			i := *calls
+			*calls++
+			if i >= len(statusCodes) {
+				i = len(statusCodes) - 1
+			}
+			return newStubResponse(statusCodes[i], `{"message":"success"}`), nil
+		}
+	}
+
+	Convey("Doesn't retry by default", t, func() {
+		var calls int
+		transport := NewTransport(WithRoundTripper(sequence(&calls, 503, 201)))
+		_, err := RawPostTrackRequestWithTransport(transport, &RawTrackRequest{})
+		So(IsRetryable(err), ShouldEqual, true)
+		So(calls, ShouldEqual, 1)
+	})
+
+	Convey("Retries server errors until they succeed", t, func() {
+		var calls int
+		transport := NewTransport(WithRoundTripper(sequence(&calls, 503, 429, 201)), WithRetryPolicy(fastPolicy()))
+		res, err := RawPostTrackRequestWithTransport(transport, &RawTrackRequest{})
+		So(err, ShouldEqual, nil)
+		So(res.Message, ShouldEqual, "success")
+		So(calls, ShouldEqual, 3)
+	})
+
+	Convey("Gives up after MaxAttempts", t, func() {
+		var calls int
+		transport := NewTransport(WithRoundTripper(sequence(&calls, 500)), WithRetryPolicy(fastPolicy()))
+		err := RawPostDeletePushTokenRequestWithTransport(transport, &RawPushTokenDeleteRequest{})
+		So(IsRetryable(err), ShouldEqual, true)
+		So(calls, ShouldEqual, 4)
+	})
+
+	Convey("Doesn't retry validation errors", t, func() {
+		var calls int
+		transport := NewTransport(WithRoundTripper(sequence(&calls, 400, 201)), WithRetryPolicy(fastPolicy()))
+		_, err := RawPostTrackRequestWithTransport(transport, &RawTrackRequest{})
+		So(err, ShouldNotEqual, nil)
+		So(calls, ShouldEqual, 1)
+	})
+
+	Convey("Only retries the configured status codes", t, func() {
+		var calls int
+		policy := fastPolicy()
+		policy.RetryableStatusCodes = []int{502}
+		transport := NewTransport(WithRoundTripper(sequence(&calls, 502, 503, 201)), WithRetryPolicy(policy))
+		_, err := RawPostTrackRequestWithTransport(transport, &RawTrackRequest{})
+		So(err, ShouldNotEqual, nil)
+		So(calls, ShouldEqual, 2)
+	})
+
+	Convey("Retries network errors", t, func() {
+		var calls int
+		rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			calls++
+			if calls == 1 {
+				return nil, errors.New("connection reset by peer")
+			}
+			return newStubResponse(201, `{"message":"success"}`), nil
+		})
+
+		transport := NewTransport(WithRoundTripper(rt), WithRetryPolicy(fastPolicy()))
+		_, err := RawPostTrackRequestWithTransport(transport, &RawTrackRequest{})
+		So(err, ShouldEqual, nil)
+		So(calls, ShouldEqual, 2)
+	})
+
+	Convey("Never retries campaign triggers unless allowed", t, func() {
+		var calls int
+		client := NewClient("foo", WithRoundTripper(sequence(&calls, 503, 201)), WithRetryPolicy(fastPolicy()))
+		ctr := client.NewCampaignTriggerRequest("my-campaign-id")
+		ctr.AddRecipient("4900", nil)
+		err := ctr.Post()
+		So(err, ShouldNotEqual, nil)
+		So(calls, ShouldEqual, 1)
+
+		calls = 0
+		policy := fastPolicy()
+		policy.RetryNonIdempotent = true
+		client = NewClient("foo", WithRoundTripper(sequence(&calls, 503, 201)), WithRetryPolicy(policy))
+		ctr = client.NewCampaignTriggerRequest("my-campaign-id")
+		ctr.AddRecipient("4900", nil)
+		err = ctr.Post()
+		So(err, ShouldEqual, nil)
+		So(calls, ShouldEqual, 2)
+	})
+
+	Convey("Never retries track requests that would record something twice unless allowed", t, func() {
+		var calls int
+		appClient := NewClient("foo", WithRoundTripper(sequence(&calls, 503, 201)), WithRetryPolicy(fastPolicy())).NewAppClient("blah")
+
+		event := NewEvent()
+		event.SetName("logged_in")
+		for _, change := range []func(*TrackRequest){
+			func(tr *TrackRequest) { tr.AddEvent(event) },
+			func(tr *TrackRequest) { checkErr(tr.IncrementCustomAttribute("logins", 1)) },
+		} {
+			calls = 0
+			tr := appClient.NewTrackRequest("holah")
+			change(tr)
+			_, err := tr.Post()
+			So(err, ShouldNotEqual, nil)
+			So(calls, ShouldEqual, 1)
+		}
+
+		// Setting values is safe to repeat
+		calls = 0
+		tr := appClient.NewTrackRequest("holah")
+		tr.SetFirstName("foo")
+		_, err := tr.Post()
+		So(err, ShouldEqual, nil)
+		So(calls, ShouldEqual, 2)
+
+		calls = 0
+		policy := fastPolicy()
+		policy.RetryNonIdempotent = true
+		appClient = NewClient("foo", WithRoundTripper(sequence(&calls, 503, 201)), WithRetryPolicy(policy)).NewAppClient("blah")
+		tr = appClient.NewTrackRequest("holah")
+		tr.AddEvent(event)
+		_, err = tr.Post()
+		So(err, ShouldEqual, nil)
+		So(calls, ShouldEqual, 2)
+	})
+
+	Convey("Stops waiting to retry when the context is done", t, func() {
+		var calls int
+		rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			calls++
+			resp := newStubResponse(429, `{"message":"slow down"}`)
+			resp.Header.Set("Retry-After", "60")
+			return resp, nil
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		policy := fastPolicy()
+		policy.MaxBackoff = time.Minute
+		transport := NewTransport(WithRoundTripper(rt), WithRetryPolicy(policy))
+		_, err := RawPostTrackRequestContext(ctx, transport, &RawTrackRequest{})
+		So(errors.Is(err, context.DeadlineExceeded), ShouldEqual, true)
+		So(calls, ShouldEqual, 1)
+	})
+
+	Convey("Backs off exponentially up to the maximum", t, func() {
+		policy := RetryPolicy{MinBackoff: time.Second, MaxBackoff: 5 * time.Second}
+		So(policy.backoff(1, nil), ShouldEqual, time.Second)
+		So(policy.backoff(2, nil), ShouldEqual, 2*time.Second)
+		So(policy.backoff(3, nil), ShouldEqual, 4*time.Second)
+		So(policy.backoff(4, nil), ShouldEqual, 5*time.Second)
+
+		policy.Jitter = 0.5
+		wait := policy.backoff(2, nil)
+		So(wait, ShouldBeLessThanOrEqualTo, 2*time.Second)
+		So(wait, ShouldBeGreaterThanOrEqualTo, time.Second)
+	})
+
+	Convey("Honours Retry-After and X-RateLimit-Reset", t, func() {
+		now := time.Unix(1000, 0)
+
+		wait, ok := retryAfter(http.Header{"Retry-After": []string{"7"}}, now)
+		So(ok, ShouldEqual, true)
+		So(wait, ShouldEqual, 7*time.Second)
+
+		wait, ok = retryAfter(http.Header{"Retry-After": []string{now.Add(3 * time.Second).UTC().Format(http.TimeFormat)}}, now)
+		So(ok, ShouldEqual, true)
+		So(wait, ShouldEqual, 3*time.Second)
+
+		wait, ok = retryAfter(http.Header{"X-Ratelimit-Reset": []string{strconv.Itoa(1012)}}, now)
+		So(ok, ShouldEqual, true)
+		So(wait, ShouldEqual, 12*time.Second)
+
+		_, ok = retryAfter(http.Header{}, now)
+		So(ok, ShouldEqual, false)
+
+		apiErr := &APIError{StatusCode: 429, Header: http.Header{"Retry-After": []string{"2"}}}
+		So(RetryPolicy{MinBackoff: time.Hour}.backoff(1, apiErr), ShouldEqual, 2*time.Second)
+
+		// Hints are capped like our own backoff
+		apiErr = &APIError{StatusCode: 429, Header: http.Header{"Retry-After": []string{"3600"}}}
+		So(RetryPolicy{MaxBackoff: 30 * time.Second}.backoff(1, apiErr), ShouldEqual, 30*time.Second)
+		So(RetryPolicy{}.backoff(1, apiErr), ShouldEqual, time.Hour)
+	})
+}
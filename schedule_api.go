@@ -0,0 +1,277 @@
+package gogo_boy
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+/*
+	----------------------------------------------------------------------
+	Raw requests for scheduling campaign and Canvas triggers
+	----------------------------------------------------------------------
+*/
+
+const (
+	CampaignScheduleCreatePath = "/campaigns/trigger/schedule/create"
+	CampaignScheduleUpdatePath = "/campaigns/trigger/schedule/update"
+	CampaignScheduleDeletePath = "/campaigns/trigger/schedule/delete"
+	CanvasScheduleCreatePath   = "/canvas/trigger/schedule/create"
+	CanvasScheduleUpdatePath   = "/canvas/trigger/schedule/update"
+	CanvasScheduleDeletePath   = "/canvas/trigger/schedule/delete"
+
+	// Full URLs on the default host
+	CampaignScheduleCreateEndpoint = DefaultBaseURL + CampaignScheduleCreatePath
+	CampaignScheduleUpdateEndpoint = DefaultBaseURL + CampaignScheduleUpdatePath
+	CampaignScheduleDeleteEndpoint = DefaultBaseURL + CampaignScheduleDeletePath
+	CanvasScheduleCreateEndpoint   = DefaultBaseURL + CanvasScheduleCreatePath
+	CanvasScheduleUpdateEndpoint   = DefaultBaseURL + CanvasScheduleUpdatePath
+	CanvasScheduleDeleteEndpoint   = DefaultBaseURL + CanvasScheduleDeletePath
+)
+
+type RawSchedule struct {
+	Time          string `json:"time"` // ISO 8601
+	InLocalTime   bool   `json:"in_local_time,omitempty"`
+	AtOptimalTime bool   `json:"at_optimal_time,omitempty"`
+}
+
+// A campaign trigger that goes out at Schedule instead of right away
+type RawScheduledCampaignTriggerRequest struct {
+	RawCampaignTriggerRequest
+	Schedule RawSchedule `json:"schedule"`
+}
+
+// A Canvas trigger that goes out at Schedule instead of right away
+type RawScheduledCanvasTriggerRequest struct {
+	RawCanvasTriggerRequest
+	Schedule RawSchedule `json:"schedule"`
+}
+
+type ScheduleResponse struct {
+	DispatchId string `json:"dispatch_id"`
+	ScheduleId string `json:"schedule_id"` // Needed to update or delete the schedule
+	Message    string `json:"message"`
+}
+
+// Set CampaignId or CanvasId to match the endpoint it's sent to, neither for
+// scheduled messages
+type RawScheduleUpdateRequest struct {
+	AppGroupId string      `json:"app_group_id,omitempty"`
+	CampaignId string      `json:"campaign_id,omitempty"`
+	CanvasId   string      `json:"canvas_id,omitempty"`
+	ScheduleId string      `json:"schedule_id"`
+	Schedule   RawSchedule `json:"schedule"`
+	Messages   *Messages   `json:"messages,omitempty"` // Scheduled messages only
+}
+
+// Set CampaignId or CanvasId to match the endpoint it's sent to, neither for
+// scheduled messages
+type RawScheduleDeleteRequest struct {
+	AppGroupId string `json:"app_group_id,omitempty"`
+	CampaignId string `json:"campaign_id,omitempty"`
+	CanvasId   string `json:"canvas_id,omitempty"`
+	ScheduleId string `json:"schedule_id"`
+}
+
+func RawPostScheduledCampaignTriggerRequest(rawReq *RawScheduledCampaignTriggerRequest) (*ScheduleResponse, error) {
+	return RawPostScheduledCampaignTriggerRequestContext(context.Background(), nil, rawReq)
+}
+
+// Same as RawPostScheduledCampaignTriggerRequest but sent over transport (nil
+// for the defaults) and aborts when ctx is done
+func RawPostScheduledCampaignTriggerRequestContext(ctx context.Context, transport *Transport, rawReq *RawScheduledCampaignTriggerRequest) (*ScheduleResponse, error) {
+	res := &ScheduleResponse{}
+	if err := transportOrDefault(transport).postJSON(ctx, "RawScheduledCampaignTriggerRequest", CampaignScheduleCreatePath, rawReq, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func RawPostScheduledCanvasTriggerRequest(rawReq *RawScheduledCanvasTriggerRequest) (*ScheduleResponse, error) {
+	return RawPostScheduledCanvasTriggerRequestContext(context.Background(), nil, rawReq)
+}
+
+// Same as RawPostScheduledCanvasTriggerRequest but sent over transport (nil
+// for the defaults) and aborts when ctx is done
+func RawPostScheduledCanvasTriggerRequestContext(ctx context.Context, transport *Transport, rawReq *RawScheduledCanvasTriggerRequest) (*ScheduleResponse, error) {
+	res := &ScheduleResponse{}
+	if err := transportOrDefault(transport).postJSON(ctx, "RawScheduledCanvasTriggerRequest", CanvasScheduleCreatePath, rawReq, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// path is CampaignScheduleUpdatePath, CanvasScheduleUpdatePath or
+// MessagesScheduleUpdatePath
+func RawPostScheduleUpdateRequest(path string, rawReq *RawScheduleUpdateRequest) error {
+	return RawPostScheduleUpdateRequestContext(context.Background(), nil, path, rawReq)
+}
+
+// Same as RawPostScheduleUpdateRequest but sent over transport (nil for the
+// defaults) and aborts when ctx is done
+func RawPostScheduleUpdateRequestContext(ctx context.Context, transport *Transport, path string, rawReq *RawScheduleUpdateRequest) error {
+	return transportOrDefault(transport).postJSON(ctx, "RawScheduleUpdateRequest", path, rawReq, nil)
+}
+
+// path is CampaignScheduleDeletePath, CanvasScheduleDeletePath or
+// MessagesScheduleDeletePath
+func RawPostScheduleDeleteRequest(path string, rawReq *RawScheduleDeleteRequest) error {
+	return RawPostScheduleDeleteRequestContext(context.Background(), nil, path, rawReq)
+}
+
+// Same as RawPostScheduleDeleteRequest but sent over transport (nil for the
+// defaults) and aborts when ctx is done
+func RawPostScheduleDeleteRequestContext(ctx context.Context, transport *Transport, path string, rawReq *RawScheduleDeleteRequest) error {
+	return transportOrDefault(transport).postJSON(ctx, "RawScheduleDeleteRequest", path, rawReq, nil)
+}
+
+/*
+	----------------------------------------------------------------------
+	Prettier versions on the trigger requests and Client
+	----------------------------------------------------------------------
+*/
+
+type Schedule struct {
+	Time time.Time
+
+	// Send at Time's date and wall clock time in each user's own time zone
+	InLocalTime bool
+
+	// Send on Time's date at the time each user is most likely to engage
+	AtOptimalTime bool
+}
+
+func (s Schedule) raw() (RawSchedule, error) {
+	if s.Time.IsZero() {
+		return RawSchedule{}, fmt.Errorf("the schedule has no time")
+	}
+	if s.InLocalTime && s.AtOptimalTime {
+		return RawSchedule{}, fmt.Errorf("the schedule can't be both in local time and at the optimal time")
+	}
+
+	t := s.Time.UTC().Format(time.RFC3339)
+	if s.InLocalTime || s.AtOptimalTime {
+		// Only the date and wall clock matter, app-boy applies each user's zone
+		t = s.Time.Format("2006-01-02T15:04:05")
+	}
+
+	return RawSchedule{
+		Time:          t,
+		InLocalTime:   s.InLocalTime,
+		AtOptimalTime: s.AtOptimalTime,
+	}, nil
+}
+
+// Same as Post but sends the campaign at schedule, the returned ScheduleId
+// is what you update or delete it by
+func (ctr *CampaignTriggerRequest) Schedule(schedule Schedule) (*ScheduleResponse, error) {
+	return ctr.ScheduleContext(context.Background(), schedule)
+}
+
+// Same as Schedule but aborts when ctx is done
+func (ctr *CampaignTriggerRequest) ScheduleContext(ctx context.Context, schedule Schedule) (*ScheduleResponse, error) {
+	rs, err := schedule.raw()
+	if err != nil {
+		return nil, fmt.Errorf("Schedule for the [AppBoyCampaign](campaign_id: %s) failed: %s", ctr.CampaignId, err)
+	}
+	if err := ctr.validate(); err != nil {
+		return nil, err
+	}
+
+	if lr := len(ctr.Recipients); lr > MaxCampaignTriggerRecipients {
+		return nil, fmt.Errorf("Tried to schedule a CampaignTriggerRequest for the [AppBoyCampaign](campaign_id: %s) but there were %d recipients which exceeds the maximum of %d per request", ctr.CampaignId, lr, MaxCampaignTriggerRecipients)
+	}
+
+	return RawPostScheduledCampaignTriggerRequestContext(ctx, ctr.transport, &RawScheduledCampaignTriggerRequest{
+		RawCampaignTriggerRequest: *ctr.rawCampaignTriggerRequest(ctr.Recipients),
+		Schedule:                  rs,
+	})
+}
+
+// Same as Post but enters users into the Canvas at schedule, the returned
+// ScheduleId is what you update or delete it by
+func (ctr *CanvasTriggerRequest) Schedule(schedule Schedule) (*ScheduleResponse, error) {
+	return ctr.ScheduleContext(context.Background(), schedule)
+}
+
+// Same as Schedule but aborts when ctx is done
+func (ctr *CanvasTriggerRequest) ScheduleContext(ctx context.Context, schedule Schedule) (*ScheduleResponse, error) {
+	rs, err := schedule.raw()
+	if err != nil {
+		return nil, fmt.Errorf("Schedule for the [AppBoyCanvas](canvas_id: %s) failed: %s", ctr.CanvasId, err)
+	}
+	if err := ctr.validate(); err != nil {
+		return nil, err
+	}
+
+	if lr := len(ctr.Recipients); lr > MaxCanvasTriggerRecipients {
+		return nil, fmt.Errorf("Tried to schedule a CanvasTriggerRequest for the [AppBoyCanvas](canvas_id: %s) but there were %d recipients which exceeds the maximum of %d per request", ctr.CanvasId, lr, MaxCanvasTriggerRecipients)
+	}
+
+	return RawPostScheduledCanvasTriggerRequestContext(ctx, ctr.transport, &RawScheduledCanvasTriggerRequest{
+		RawCanvasTriggerRequest: *ctr.rawCanvasTriggerRequest(ctr.Recipients),
+		Schedule:                rs,
+	})
+}
+
+func (c *Client) UpdateCampaignTriggerSchedule(campaignId, scheduleId string, schedule Schedule) error {
+	return c.UpdateCampaignTriggerScheduleContext(context.Background(), campaignId, scheduleId, schedule)
+}
+
+// Same as UpdateCampaignTriggerSchedule but aborts when ctx is done
+func (c *Client) UpdateCampaignTriggerScheduleContext(ctx context.Context, campaignId, scheduleId string, schedule Schedule) error {
+	return c.updateSchedule(ctx, CampaignScheduleUpdatePath, &RawScheduleUpdateRequest{CampaignId: campaignId, ScheduleId: scheduleId}, schedule)
+}
+
+func (c *Client) DeleteCampaignTriggerSchedule(campaignId, scheduleId string) error {
+	return c.DeleteCampaignTriggerScheduleContext(context.Background(), campaignId, scheduleId)
+}
+
+// Same as DeleteCampaignTriggerSchedule but aborts when ctx is done
+func (c *Client) DeleteCampaignTriggerScheduleContext(ctx context.Context, campaignId, scheduleId string) error {
+	return c.deleteSchedule(ctx, CampaignScheduleDeletePath, &RawScheduleDeleteRequest{CampaignId: campaignId, ScheduleId: scheduleId})
+}
+
+func (c *Client) UpdateCanvasTriggerSchedule(canvasId, scheduleId string, schedule Schedule) error {
+	return c.UpdateCanvasTriggerScheduleContext(context.Background(), canvasId, scheduleId, schedule)
+}
+
+// Same as UpdateCanvasTriggerSchedule but aborts when ctx is done
+func (c *Client) UpdateCanvasTriggerScheduleContext(ctx context.Context, canvasId, scheduleId string, schedule Schedule) error {
+	return c.updateSchedule(ctx, CanvasScheduleUpdatePath, &RawScheduleUpdateRequest{CanvasId: canvasId, ScheduleId: scheduleId}, schedule)
+}
+
+func (c *Client) DeleteCanvasTriggerSchedule(canvasId, scheduleId string) error {
+	return c.DeleteCanvasTriggerScheduleContext(context.Background(), canvasId, scheduleId)
+}
+
+// Same as DeleteCanvasTriggerSchedule but aborts when ctx is done
+func (c *Client) DeleteCanvasTriggerScheduleContext(ctx context.Context, canvasId, scheduleId string) error {
+	return c.deleteSchedule(ctx, CanvasScheduleDeletePath, &RawScheduleDeleteRequest{CanvasId: canvasId, ScheduleId: scheduleId})
+}
+
+func (c *Client) updateSchedule(ctx context.Context, path string, req *RawScheduleUpdateRequest, schedule Schedule) error {
+	if req.ScheduleId == "" {
+		return fmt.Errorf("RawScheduleUpdateRequest failed: no schedule_id was given")
+	}
+
+	rs, err := schedule.raw()
+	if err != nil {
+		return fmt.Errorf("RawScheduleUpdateRequest for (schedule_id: %s) failed: %s", req.ScheduleId, err)
+	}
+
+	req.AppGroupId = c.appGroupId
+	req.Schedule = rs
+	return RawPostScheduleUpdateRequestContext(ctx, c.transport, path, req)
+}
+
+func (c *Client) deleteSchedule(ctx context.Context, path string, req *RawScheduleDeleteRequest) error {
+	if req.ScheduleId == "" {
+		return fmt.Errorf("RawScheduleDeleteRequest failed: no schedule_id was given")
+	}
+
+	req.AppGroupId = c.appGroupId
+	return RawPostScheduleDeleteRequestContext(ctx, c.transport, path, req)
+}
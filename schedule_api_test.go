@@ -0,0 +1,113 @@
+package gogo_boy
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestScheduleAPI(t *testing.T) {
+	var client *Client
+	before := func() {
+		client = NewClient("foo")
+	}
+
+	after := func() {
+		StopMocks()
+	}
+
+	at := time.Date(2030, 1, 2, 15, 4, 5, 0, time.FixedZone("EST", -5*60*60))
+
+	Convey("Can schedule a campaign trigger", t, func() {
+		before()
+		defer after()
+
+		// Mock request to app-boy
+		var request map[string]interface{}
+		MockCampaignScheduleCreateSuccess(func(_request map[string]interface{}) { request = _request })
+
+		ctr := client.NewCampaignTriggerRequest("my-campaign-id")
+		ctr.AddRecipient("holah", map[string]interface{}{"foo": "bar"})
+		res, err := ctr.Schedule(Schedule{Time: at})
+		checkErr(err)
+		So(res.ScheduleId, ShouldEqual, "schedule-1")
+		So(res.DispatchId, ShouldEqual, "dispatch-1")
+		So(request["campaign_id"], ShouldEqual, "my-campaign-id")
+		So(len(request["recipients"].([]interface{})), ShouldEqual, 1)
+		So(request["schedule"], ShouldResemble, map[string]interface{}{"time": "2030-01-02T20:04:05Z"})
+	})
+
+	Convey("Can schedule a canvas trigger in each user's local time", t, func() {
+		before()
+		defer after()
+
+		// Mock request to app-boy
+		var request map[string]interface{}
+		MockCanvasScheduleCreateSuccess(func(_request map[string]interface{}) { request = _request })
+
+		ctr := client.NewCanvasTriggerRequest("my-canvas-id")
+		ctr.Broadcast = true
+		res, err := ctr.Schedule(Schedule{Time: at, InLocalTime: true})
+		checkErr(err)
+		So(res.ScheduleId, ShouldEqual, "schedule-1")
+		So(request["canvas_id"], ShouldEqual, "my-canvas-id")
+		So(request["broadcast"], ShouldEqual, true)
+		So(request["schedule"], ShouldResemble, map[string]interface{}{"time": "2030-01-02T15:04:05", "in_local_time": true})
+	})
+
+	Convey("Can update and delete schedules", t, func() {
+		before()
+		defer after()
+
+		// Mock requests to app-boy
+		requests := map[string]map[string]interface{}{}
+		for _, endpoint := range []string{CampaignScheduleUpdateEndpoint, CampaignScheduleDeleteEndpoint, CanvasScheduleUpdateEndpoint, CanvasScheduleDeleteEndpoint} {
+			endpoint := endpoint
+			MockScheduleChangeSuccess(endpoint, func(_request map[string]interface{}) { requests[endpoint] = _request })
+		}
+
+		checkErr(client.UpdateCampaignTriggerSchedule("my-campaign-id", "schedule-1", Schedule{Time: at, AtOptimalTime: true}))
+		checkErr(client.DeleteCampaignTriggerSchedule("my-campaign-id", "schedule-1"))
+		checkErr(client.UpdateCanvasTriggerSchedule("my-canvas-id", "schedule-2", Schedule{Time: at}))
+		checkErr(client.DeleteCanvasTriggerSchedule("my-canvas-id", "schedule-2"))
+
+		So(requests[CampaignScheduleUpdateEndpoint], ShouldResemble, map[string]interface{}{
+			"app_group_id": "foo",
+			"campaign_id":  "my-campaign-id",
+			"schedule_id":  "schedule-1",
+			"schedule":     map[string]interface{}{"time": "2030-01-02T15:04:05", "at_optimal_time": true},
+		})
+		So(requests[CampaignScheduleDeleteEndpoint], ShouldResemble, map[string]interface{}{
+			"app_group_id": "foo",
+			"campaign_id":  "my-campaign-id",
+			"schedule_id":  "schedule-1",
+		})
+		So(requests[CanvasScheduleUpdateEndpoint]["canvas_id"], ShouldEqual, "my-canvas-id")
+		So(requests[CanvasScheduleDeleteEndpoint]["schedule_id"], ShouldEqual, "schedule-2")
+	})
+
+	Convey("Rejects schedules app-boy doesn't accept", t, func() {
+		before()
+
+		ctr := client.NewCampaignTriggerRequest("my-campaign-id")
+		_, err := ctr.Schedule(Schedule{})
+		So(err, ShouldNotEqual, nil)
+		_, err = ctr.Schedule(Schedule{Time: at, InLocalTime: true, AtOptimalTime: true})
+		So(err, ShouldNotEqual, nil)
+		So(client.DeleteCampaignTriggerSchedule("my-campaign-id", ""), ShouldNotEqual, nil)
+	})
+
+	Convey("Returns app-boy's errors", t, func() {
+		before()
+		defer after()
+
+		MockScheduleFailure(CanvasScheduleDeleteEndpoint, func(map[string]interface{}) {})
+
+		err := client.DeleteCanvasTriggerSchedule("my-canvas-id", "nope")
+		var apiErr *APIError
+		So(errors.As(err, &apiErr), ShouldEqual, true)
+		So(apiErr.Message, ShouldEqual, "Invalid schedule_id")
+	})
+}
@@ -0,0 +1,195 @@
+package gogo_boy
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+/*
+	----------------------------------------------------------------------
+	Raw requests for send ids and their analytics
+	----------------------------------------------------------------------
+*/
+
+const (
+	SendIdCreatePath   = "/sends/id/create"
+	SendDataSeriesPath = "/sends/data_series"
+
+	// Full URLs on the default host
+	SendIdCreateEndpoint   = DefaultBaseURL + SendIdCreatePath
+	SendDataSeriesEndpoint = DefaultBaseURL + SendDataSeriesPath
+)
+
+// App-boy keeps send analytics for this many days
+const MaxSendDataSeriesDays = 100
+
+// Leave SendId empty to have app-boy generate one
+type RawSendIdCreateRequest struct {
+	AppGroupId string `json:"app_group_id,omitempty"`
+	CampaignId string `json:"campaign_id"`
+	SendId     string `json:"send_id,omitempty"`
+}
+
+type SendIdCreateResponse struct {
+	SendId  string `json:"send_id"`
+	Message string `json:"message"`
+}
+
+// Length is the number of days up to EndingAt (zero for now)
+type RawSendDataSeriesRequest struct {
+	AppGroupId string
+	CampaignId string
+	SendId     string
+	Length     int
+	EndingAt   time.Time
+}
+
+func (r *RawSendDataSeriesRequest) query() url.Values {
+	query := url.Values{
+		"campaign_id": {r.CampaignId},
+		"send_id":     {r.SendId},
+		"length":      {strconv.Itoa(r.Length)},
+	}
+	if r.AppGroupId != "" {
+		query.Set("app_group_id", r.AppGroupId)
+	}
+	if !r.EndingAt.IsZero() {
+		query.Set("ending_at", r.EndingAt.UTC().Format(time.RFC3339))
+	}
+
+	return query
+}
+
+type SendDataSeriesResponse struct {
+	Data    []SendDataPoint `json:"data"`
+	Message string          `json:"message"`
+}
+
+// A day's worth of stats for the send
+type SendDataPoint struct {
+	Time                  string                        `json:"time"`     // The day, e.g. "2030-01-02"
+	Messages              map[string][]SendMessageStats `json:"messages"` // By channel, e.g. "ios_push" or "email"
+	Conversions           int                           `json:"conversions"`
+	ConversionsBySendTime int                           `json:"conversions_by_send_time"`
+	UniqueRecipients      int                           `json:"unique_recipients"`
+	Revenue               float64                       `json:"revenue"`
+}
+
+// Channels only fill in the stats that apply to them
+type SendMessageStats struct {
+	VariationName         string  `json:"variation_name"`
+	Sent                  int     `json:"sent"`
+	Delivered             int     `json:"delivered"`
+	Undelivered           int     `json:"undelivered"`
+	DeliveryFailed        int     `json:"delivery_failed"`
+	DirectOpens           int     `json:"direct_opens"`
+	TotalOpens            int     `json:"total_opens"`
+	Opens                 int     `json:"opens"`
+	UniqueOpens           int     `json:"unique_opens"`
+	Clicks                int     `json:"clicks"`
+	UniqueClicks          int     `json:"unique_clicks"`
+	BodyClicks            int     `json:"body_clicks"`
+	Bounces               int     `json:"bounces"`
+	Unsubscribes          int     `json:"unsubscribes"`
+	Conversions           int     `json:"conversions"`
+	ConversionsBySendTime int     `json:"conversions_by_send_time"`
+	UniqueRecipients      int     `json:"unique_recipients"`
+	Revenue               float64 `json:"revenue"`
+}
+
+func RawPostSendIdCreateRequest(rawReq *RawSendIdCreateRequest) (*SendIdCreateResponse, error) {
+	return RawPostSendIdCreateRequestContext(context.Background(), nil, rawReq)
+}
+
+// Same as RawPostSendIdCreateRequest but sent over transport (nil for the
+// defaults) and aborts when ctx is done
+func RawPostSendIdCreateRequestContext(ctx context.Context, transport *Transport, rawReq *RawSendIdCreateRequest) (*SendIdCreateResponse, error) {
+	res := &SendIdCreateResponse{}
+	if err := transportOrDefault(transport).postJSON(ctx, "RawSendIdCreateRequest", SendIdCreatePath, rawReq, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func RawGetSendDataSeries(rawReq *RawSendDataSeriesRequest) (*SendDataSeriesResponse, error) {
+	return RawGetSendDataSeriesContext(context.Background(), nil, rawReq)
+}
+
+// Same as RawGetSendDataSeries but sent over transport (nil for the defaults)
+// and aborts when ctx is done
+func RawGetSendDataSeriesContext(ctx context.Context, transport *Transport, rawReq *RawSendDataSeriesRequest) (*SendDataSeriesResponse, error) {
+	res := &SendDataSeriesResponse{}
+	if err := transportOrDefault(transport).getJSON(ctx, "RawGetSendDataSeries", SendDataSeriesPath, rawReq.query(), res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+/*
+	----------------------------------------------------------------------
+	Prettier versions on Client
+	----------------------------------------------------------------------
+*/
+
+// Registers sendId for the API campaign so triggers and message sends can
+// report under it (set their SendId). An empty sendId has app-boy generate
+// one, either way the send id is returned. App-boy allows 100 a day per
+// campaign, going over fails with an APIError that IsRateLimited.
+func (c *Client) CreateSendId(campaignId, sendId string) (string, error) {
+	return c.CreateSendIdContext(context.Background(), campaignId, sendId)
+}
+
+// Same as CreateSendId but aborts when ctx is done
+func (c *Client) CreateSendIdContext(ctx context.Context, campaignId, sendId string) (string, error) {
+	if campaignId == "" {
+		return "", fmt.Errorf("CreateSendId failed: no campaign_id was given")
+	}
+	if len(sendId) > MaxSendIdLength {
+		return "", fmt.Errorf("CreateSendId for the [AppBoyCampaign](campaign_id: %s) failed: the send_id is longer than %d characters", campaignId, MaxSendIdLength)
+	}
+
+	res, err := RawPostSendIdCreateRequestContext(ctx, c.transport, &RawSendIdCreateRequest{
+		AppGroupId: c.appGroupId,
+		CampaignId: campaignId,
+		SendId:     sendId,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return res.SendId, nil
+}
+
+// Daily stats for the send over the given number of days (at most
+// MaxSendDataSeriesDays) up to endingAt, zero for now
+func (c *Client) SendDataSeries(campaignId, sendId string, days int, endingAt time.Time) ([]SendDataPoint, error) {
+	return c.SendDataSeriesContext(context.Background(), campaignId, sendId, days, endingAt)
+}
+
+// Same as SendDataSeries but aborts when ctx is done
+func (c *Client) SendDataSeriesContext(ctx context.Context, campaignId, sendId string, days int, endingAt time.Time) ([]SendDataPoint, error) {
+	if campaignId == "" || sendId == "" {
+		return nil, fmt.Errorf("SendDataSeries failed: a campaign_id and send_id are needed")
+	}
+	if days < 1 || days > MaxSendDataSeriesDays {
+		return nil, fmt.Errorf("SendDataSeries for (send_id: %s) failed: %d days is not between 1 and %d", sendId, days, MaxSendDataSeriesDays)
+	}
+
+	res, err := RawGetSendDataSeriesContext(ctx, c.transport, &RawSendDataSeriesRequest{
+		AppGroupId: c.appGroupId,
+		CampaignId: campaignId,
+		SendId:     sendId,
+		Length:     days,
+		EndingAt:   endingAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Data, nil
+}
@@ -0,0 +1,125 @@
+package gogo_boy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSendsAPI(t *testing.T) {
+	var client *Client
+	before := func() {
+		client = NewClient("foo")
+	}
+
+	after := func() {
+		StopMocks()
+	}
+
+	Convey("Can create a send id and trigger under it", t, func() {
+		before()
+		defer after()
+
+		// Mock requests to app-boy
+		var createRequest, triggerRequest map[string]interface{}
+		MockSendIdCreateSuccess(func(_request map[string]interface{}) { createRequest = _request })
+		MockCampaignTriggerSuccess(func(_request map[string]interface{}) { triggerRequest = _request })
+
+		sendId, err := client.CreateSendId("my-campaign-id", "spring-sale")
+		checkErr(err)
+		So(sendId, ShouldEqual, "spring-sale")
+		So(createRequest, ShouldResemble, map[string]interface{}{"app_group_id": "foo", "campaign_id": "my-campaign-id", "send_id": "spring-sale"})
+
+		ctr := client.NewCampaignTriggerRequest("my-campaign-id")
+		ctr.SendId = sendId
+		ctr.AddRecipient("holah", nil)
+		checkErr(ctr.Post())
+		So(triggerRequest["send_id"], ShouldEqual, "spring-sale")
+
+		// Left out unless it's set
+		ctr.SendId = ""
+		checkErr(ctr.Post())
+		_, ok := triggerRequest["send_id"]
+		So(ok, ShouldEqual, false)
+	})
+
+	Convey("The default rate limits don't hold send ids for one campaign up on another's", t, func() {
+		defer after()
+
+		MockSendIdCreateSuccess(func(_request map[string]interface{}) {})
+		client := NewClient("foo", WithRateLimits(DefaultRateLimits()))
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		// App-boy's budget is 100 a day per campaign
+		for i := 0; i < 101; i++ {
+			_, err := client.CreateSendIdContext(ctx, fmt.Sprintf("campaign-%d", i), "")
+			checkErr(err)
+		}
+	})
+
+	Convey("Rejects send ids app-boy wouldn't take", t, func() {
+		before()
+
+		_, err := client.CreateSendId("", "spring-sale")
+		So(err, ShouldNotEqual, nil)
+		_, err = client.CreateSendId("my-campaign-id", string(make([]byte, MaxSendIdLength+1)))
+		So(err, ShouldNotEqual, nil)
+	})
+
+	Convey("Can get a send's data series", t, func() {
+		before()
+		defer after()
+
+		// Mock request to app-boy
+		var query url.Values
+		MockSendDataSeriesSuccess(func(_query url.Values) { query = _query })
+
+		data, err := client.SendDataSeries("my-campaign-id", "spring-sale", 7, time.Date(2030, 1, 3, 0, 0, 0, 0, time.UTC))
+		checkErr(err)
+		So(query, ShouldResemble, url.Values{
+			"app_group_id": {"foo"},
+			"campaign_id":  {"my-campaign-id"},
+			"send_id":      {"spring-sale"},
+			"length":       {"7"},
+			"ending_at":    {"2030-01-03T00:00:00Z"},
+		})
+		So(len(data), ShouldEqual, 2)
+		So(data[0].Time, ShouldEqual, "2030-01-01")
+		So(data[0].UniqueRecipients, ShouldEqual, 150)
+		So(data[0].Revenue, ShouldEqual, 19.98)
+		So(data[0].Messages["ios_push"][0].Delivered, ShouldEqual, 98)
+		So(data[0].Messages["email"][0].UniqueOpens, ShouldEqual, 25)
+
+		// Ends now by default
+		_, err = client.SendDataSeries("my-campaign-id", "spring-sale", 1, time.Time{})
+		checkErr(err)
+		_, ok := query["ending_at"]
+		So(ok, ShouldEqual, false)
+
+		_, err = client.SendDataSeries("my-campaign-id", "spring-sale", MaxSendDataSeriesDays+1, time.Time{})
+		So(err, ShouldNotEqual, nil)
+		_, err = client.SendDataSeries("my-campaign-id", "", 7, time.Time{})
+		So(err, ShouldNotEqual, nil)
+	})
+
+	Convey("Failed requests return an APIError", t, func() {
+		before()
+		defer after()
+
+		MockSendIdCreateFailure(func(_request map[string]interface{}) {})
+		MockSendDataSeriesFailure(func(_query url.Values) {})
+
+		var apiErr *APIError
+		_, err := client.CreateSendId("my-campaign-id", "")
+		So(errors.As(err, &apiErr), ShouldEqual, true)
+		_, err = client.SendDataSeries("my-campaign-id", "spring-sale", 7, time.Time{})
+		So(errors.As(err, &apiErr), ShouldEqual, true)
+		So(apiErr.StatusCode, ShouldEqual, 400)
+	})
+}
@@ -0,0 +1,342 @@
+package gogo_boy
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+)
+
+/*
+	----------------------------------------------------------------------
+	Raw requests for email and SMS subscription groups
+	----------------------------------------------------------------------
+*/
+
+const (
+	SubscriptionStatusSetPath  = "/subscription/status/set"
+	SubscriptionUserStatusPath = "/subscription/user/status"
+	SubscriptionStatusGetPath  = "/subscription/status/get"
+
+	// Full URLs on the default host
+	SubscriptionStatusSetEndpoint  = DefaultBaseURL + SubscriptionStatusSetPath
+	SubscriptionUserStatusEndpoint = DefaultBaseURL + SubscriptionUserStatusPath
+	SubscriptionStatusGetEndpoint  = DefaultBaseURL + SubscriptionStatusGetPath
+)
+
+// What SubscriptionStatuses reports for each user
+const (
+	SubscriptionGroupSubscribed   = "Subscribed"
+	SubscriptionGroupUnsubscribed = "Unsubscribed"
+	SubscriptionGroupUnknown      = "Unknown"
+)
+
+// E.164, e.g. "+12223334444"
+var phoneRegexp = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+
+// Emails only work for email groups and Phones for SMS groups
+type RawSubscriptionStatusSetRequest struct {
+	AppGroupId          string            `json:"app_group_id,omitempty"`
+	SubscriptionGroupId string            `json:"subscription_group_id"`
+	SubscriptionState   SubscriptionState `json:"subscription_state"` // Subscribed or unsubscribed
+	ExternalIds         []string          `json:"external_id,omitempty"`
+	Emails              []string          `json:"email,omitempty"`
+	Phones              []string          `json:"phone,omitempty"`
+}
+
+// Limit and Offset page through the results, zero leaves them to app-boy
+type RawSubscriptionUserStatusRequest struct {
+	AppGroupId  string
+	ExternalIds []string
+	Emails      []string
+	Phones      []string
+	Limit       int
+	Offset      int
+}
+
+func (r *RawSubscriptionUserStatusRequest) query() url.Values {
+	query := subscriptionQuery(r.AppGroupId, r.ExternalIds, r.Emails, r.Phones)
+	if r.Limit > 0 {
+		query.Set("limit", strconv.Itoa(r.Limit))
+	}
+	if r.Offset > 0 {
+		query.Set("offset", strconv.Itoa(r.Offset))
+	}
+
+	return query
+}
+
+type SubscriptionUserStatusResponse struct {
+	Users   []SubscriptionUser `json:"users"`
+	Message string             `json:"message"`
+}
+
+// A user and the groups they're subscribed to
+type SubscriptionUser struct {
+	ExternalId         string              `json:"external_id"`
+	Email              string              `json:"email"`
+	Phone              string              `json:"phone"`
+	SubscriptionGroups []SubscriptionGroup `json:"subscription_groups"`
+}
+
+type SubscriptionGroup struct {
+	Id      string `json:"id"`
+	Name    string `json:"name"`
+	Channel string `json:"channel"` // "email" or "sms"
+	Status  string `json:"status"`
+}
+
+type RawSubscriptionStatusGetRequest struct {
+	AppGroupId          string
+	SubscriptionGroupId string
+	ExternalIds         []string
+	Emails              []string
+	Phones              []string
+}
+
+func (r *RawSubscriptionStatusGetRequest) query() url.Values {
+	query := subscriptionQuery(r.AppGroupId, r.ExternalIds, r.Emails, r.Phones)
+	query.Set("subscription_group_id", r.SubscriptionGroupId)
+
+	return query
+}
+
+type SubscriptionStatusGetResponse struct {
+	Status  map[string]string `json:"status"` // By the external id, email or phone asked about
+	Message string            `json:"message"`
+}
+
+func subscriptionQuery(appGroupId string, externalIds, emails, phones []string) url.Values {
+	query := url.Values{}
+	if appGroupId != "" {
+		query.Set("app_group_id", appGroupId)
+	}
+	for _, externalId := range externalIds {
+		query.Add("external_id[]", externalId)
+	}
+	for _, email := range emails {
+		query.Add("email[]", email)
+	}
+	for _, phone := range phones {
+		query.Add("phone[]", phone)
+	}
+
+	return query
+}
+
+func RawPostSubscriptionStatusSetRequest(rawReq *RawSubscriptionStatusSetRequest) error {
+	return RawPostSubscriptionStatusSetRequestContext(context.Background(), nil, rawReq)
+}
+
+// Same as RawPostSubscriptionStatusSetRequest but sent over transport (nil
+// for the defaults) and aborts when ctx is done
+func RawPostSubscriptionStatusSetRequestContext(ctx context.Context, transport *Transport, rawReq *RawSubscriptionStatusSetRequest) error {
+	return transportOrDefault(transport).postJSON(ctx, "RawSubscriptionStatusSetRequest", SubscriptionStatusSetPath, rawReq, nil)
+}
+
+func RawGetSubscriptionUserStatus(rawReq *RawSubscriptionUserStatusRequest) (*SubscriptionUserStatusResponse, error) {
+	return RawGetSubscriptionUserStatusContext(context.Background(), nil, rawReq)
+}
+
+// Same as RawGetSubscriptionUserStatus but sent over transport (nil for the
+// defaults) and aborts when ctx is done
+func RawGetSubscriptionUserStatusContext(ctx context.Context, transport *Transport, rawReq *RawSubscriptionUserStatusRequest) (*SubscriptionUserStatusResponse, error) {
+	res := &SubscriptionUserStatusResponse{}
+	if err := transportOrDefault(transport).getJSON(ctx, "RawGetSubscriptionUserStatus", SubscriptionUserStatusPath, rawReq.query(), res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func RawGetSubscriptionStatus(rawReq *RawSubscriptionStatusGetRequest) (*SubscriptionStatusGetResponse, error) {
+	return RawGetSubscriptionStatusContext(context.Background(), nil, rawReq)
+}
+
+// Same as RawGetSubscriptionStatus but sent over transport (nil for the
+// defaults) and aborts when ctx is done
+func RawGetSubscriptionStatusContext(ctx context.Context, transport *Transport, rawReq *RawSubscriptionStatusGetRequest) (*SubscriptionStatusGetResponse, error) {
+	res := &SubscriptionStatusGetResponse{}
+	if err := transportOrDefault(transport).getJSON(ctx, "RawGetSubscriptionStatus", SubscriptionStatusGetPath, rawReq.query(), res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+/*
+	----------------------------------------------------------------------
+	Prettier versions on Client, which take any number of users
+	----------------------------------------------------------------------
+*/
+
+// Users by external id, email and/or phone (E.164)
+type SubscriptionUsers struct {
+	ExternalIds []string
+	Emails      []string
+	Phones      []string
+}
+
+func (u SubscriptionUsers) count() int {
+	return len(u.ExternalIds) + len(u.Emails) + len(u.Phones)
+}
+
+func (u SubscriptionUsers) validate() error {
+	if u.count() == 0 {
+		return fmt.Errorf("no users were given")
+	}
+	for _, phone := range u.Phones {
+		if !phoneRegexp.MatchString(phone) {
+			return fmt.Errorf("%q is not an E.164 phone number", phone)
+		}
+	}
+
+	return nil
+}
+
+// Splits the users into batches of at most MaxUsersPerRequest, each batch
+// only has one kind of identifier
+func (u SubscriptionUsers) chunks() []SubscriptionUsers {
+	chunks := []SubscriptionUsers{}
+	for _, r := range chunkRanges(len(u.ExternalIds), MaxUsersPerRequest) {
+		chunks = append(chunks, SubscriptionUsers{ExternalIds: u.ExternalIds[r[0]:r[1]]})
+	}
+	for _, r := range chunkRanges(len(u.Emails), MaxUsersPerRequest) {
+		chunks = append(chunks, SubscriptionUsers{Emails: u.Emails[r[0]:r[1]]})
+	}
+	for _, r := range chunkRanges(len(u.Phones), MaxUsersPerRequest) {
+		chunks = append(chunks, SubscriptionUsers{Phones: u.Phones[r[0]:r[1]]})
+	}
+
+	return chunks
+}
+
+func (u *SubscriptionUsers) add(other SubscriptionUsers) {
+	u.ExternalIds = append(u.ExternalIds, other.ExternalIds...)
+	u.Emails = append(u.Emails, other.Emails...)
+	u.Phones = append(u.Phones, other.Phones...)
+}
+
+// Subscribes (or unsubscribes) the users to the group, sending them in
+// batches of MaxUsersPerRequest one after the other. Users in batches that
+// failed are returned, handy for trying again, and the error summarises the
+// failed batches.
+func (c *Client) SetSubscriptionStatus(subscriptionGroupId string, state SubscriptionState, users SubscriptionUsers) (SubscriptionUsers, error) {
+	return c.SetSubscriptionStatusContext(context.Background(), subscriptionGroupId, state, users)
+}
+
+// Same as SetSubscriptionStatus but aborts when ctx is done, users that
+// weren't sent yet are returned as failed
+func (c *Client) SetSubscriptionStatusContext(ctx context.Context, subscriptionGroupId string, state SubscriptionState, users SubscriptionUsers) (SubscriptionUsers, error) {
+	if subscriptionGroupId == "" {
+		return SubscriptionUsers{}, fmt.Errorf("SetSubscriptionStatus failed: no subscription_group_id was given")
+	}
+	if state != SubscriptionSubscribed && state != SubscriptionUnsubscribed {
+		return SubscriptionUsers{}, fmt.Errorf("SetSubscriptionStatus for (subscription_group_id: %s) failed: subscription groups are either %s or %s, not %q", subscriptionGroupId, SubscriptionSubscribed, SubscriptionUnsubscribed, state)
+	}
+	if err := users.validate(); err != nil {
+		return SubscriptionUsers{}, fmt.Errorf("SetSubscriptionStatus for (subscription_group_id: %s) failed: %s", subscriptionGroupId, err)
+	}
+
+	chunks := users.chunks()
+	errs := postChunks(ctx, len(chunks), 1, func(ctx context.Context, i int) error {
+		return RawPostSubscriptionStatusSetRequestContext(ctx, c.transport, &RawSubscriptionStatusSetRequest{
+			AppGroupId:          c.appGroupId,
+			SubscriptionGroupId: subscriptionGroupId,
+			SubscriptionState:   state,
+			ExternalIds:         chunks[i].ExternalIds,
+			Emails:              chunks[i].Emails,
+			Phones:              chunks[i].Phones,
+		})
+	})
+
+	failed := SubscriptionUsers{}
+	for i, err := range errs {
+		if err != nil {
+			failed.add(chunks[i])
+		}
+	}
+
+	return failed, chunksError("SetSubscriptionStatus", errs)
+}
+
+// Each user's status in the group (SubscriptionGroupSubscribed,
+// SubscriptionGroupUnsubscribed or SubscriptionGroupUnknown) by the external
+// id, email or phone they were given as. Batched like SetSubscriptionStatus,
+// users in batches that failed are left out.
+func (c *Client) SubscriptionStatuses(subscriptionGroupId string, users SubscriptionUsers) (map[string]string, error) {
+	return c.SubscriptionStatusesContext(context.Background(), subscriptionGroupId, users)
+}
+
+// Same as SubscriptionStatuses but aborts when ctx is done
+func (c *Client) SubscriptionStatusesContext(ctx context.Context, subscriptionGroupId string, users SubscriptionUsers) (map[string]string, error) {
+	if subscriptionGroupId == "" {
+		return nil, fmt.Errorf("SubscriptionStatuses failed: no subscription_group_id was given")
+	}
+	if err := users.validate(); err != nil {
+		return nil, fmt.Errorf("SubscriptionStatuses for (subscription_group_id: %s) failed: %s", subscriptionGroupId, err)
+	}
+
+	chunks := users.chunks()
+	responses := make([]*SubscriptionStatusGetResponse, len(chunks))
+	errs := postChunks(ctx, len(chunks), 1, func(ctx context.Context, i int) error {
+		var err error
+		responses[i], err = RawGetSubscriptionStatusContext(ctx, c.transport, &RawSubscriptionStatusGetRequest{
+			AppGroupId:          c.appGroupId,
+			SubscriptionGroupId: subscriptionGroupId,
+			ExternalIds:         chunks[i].ExternalIds,
+			Emails:              chunks[i].Emails,
+			Phones:              chunks[i].Phones,
+		})
+		return err
+	})
+
+	statuses := map[string]string{}
+	for i, res := range responses {
+		if errs[i] != nil {
+			continue
+		}
+		for user, status := range res.Status {
+			statuses[user] = status
+		}
+	}
+
+	return statuses, chunksError("SubscriptionStatuses", errs)
+}
+
+// The subscription groups each user is in. Batched like
+// SetSubscriptionStatus, users in batches that failed are left out. Users in
+// lots of groups may need RawGetSubscriptionUserStatus's paging.
+func (c *Client) UserSubscriptionGroups(users SubscriptionUsers) ([]SubscriptionUser, error) {
+	return c.UserSubscriptionGroupsContext(context.Background(), users)
+}
+
+// Same as UserSubscriptionGroups but aborts when ctx is done
+func (c *Client) UserSubscriptionGroupsContext(ctx context.Context, users SubscriptionUsers) ([]SubscriptionUser, error) {
+	if err := users.validate(); err != nil {
+		return nil, fmt.Errorf("UserSubscriptionGroups failed: %s", err)
+	}
+
+	chunks := users.chunks()
+	responses := make([]*SubscriptionUserStatusResponse, len(chunks))
+	errs := postChunks(ctx, len(chunks), 1, func(ctx context.Context, i int) error {
+		var err error
+		responses[i], err = RawGetSubscriptionUserStatusContext(ctx, c.transport, &RawSubscriptionUserStatusRequest{
+			AppGroupId:  c.appGroupId,
+			ExternalIds: chunks[i].ExternalIds,
+			Emails:      chunks[i].Emails,
+			Phones:      chunks[i].Phones,
+		})
+		return err
+	})
+
+	found := []SubscriptionUser{}
+	for i, res := range responses {
+		if errs[i] == nil {
+			found = append(found, res.Users...)
+		}
+	}
+
+	return found, chunksError("UserSubscriptionGroups", errs)
+}
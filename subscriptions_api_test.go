@@ -0,0 +1,135 @@
+package gogo_boy
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSubscriptionsAPI(t *testing.T) {
+	var client *Client
+	before := func() {
+		client = NewClient("foo")
+	}
+
+	after := func() {
+		StopMocks()
+	}
+
+	externalIds := func(n int) []string {
+		ids := []string{}
+		for i := 0; i < n; i++ {
+			ids = append(ids, fmt.Sprintf("user-%d", i))
+		}
+		return ids
+	}
+
+	Convey("Can set subscription statuses in batches", t, func() {
+		before()
+		defer after()
+
+		// Mock requests to app-boy
+		requests := []map[string]interface{}{}
+		MockSubscriptionStatusSetSuccess(func(_request map[string]interface{}) { requests = append(requests, _request) })
+
+		failed, err := client.SetSubscriptionStatus("email-group", SubscriptionSubscribed, SubscriptionUsers{
+			ExternalIds: externalIds(MaxUsersPerRequest + 1),
+			Emails:      []string{"a@example.com"},
+		})
+		checkErr(err)
+		So(failed.count(), ShouldEqual, 0)
+		So(len(requests), ShouldEqual, 3)
+		So(requests[0]["subscription_group_id"], ShouldEqual, "email-group")
+		So(requests[0]["subscription_state"], ShouldEqual, "subscribed")
+		So(requests[0]["app_group_id"], ShouldEqual, "foo")
+		So(len(requests[0]["external_id"].([]interface{})), ShouldEqual, MaxUsersPerRequest)
+		So(requests[1]["external_id"], ShouldResemble, []interface{}{fmt.Sprintf("user-%d", MaxUsersPerRequest)})
+		So(requests[2]["email"], ShouldResemble, []interface{}{"a@example.com"})
+		_, ok := requests[2]["external_id"]
+		So(ok, ShouldEqual, false)
+	})
+
+	Convey("Returns the users in failed batches", t, func() {
+		var calls int
+		rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			calls++
+			b, _ := ioutil.ReadAll(req.Body)
+			if strings.Contains(string(b), `"phone"`) {
+				return newStubResponse(400, getFixtureWithPath("subscription_res_err.json")), nil
+			}
+			return newStubResponse(201, getFixtureWithPath("subscription_status_set_res.json")), nil
+		})
+
+		client := NewClient("foo", WithRoundTripper(rt))
+		failed, err := client.SetSubscriptionStatus("sms-group", SubscriptionUnsubscribed, SubscriptionUsers{
+			ExternalIds: []string{"holah"},
+			Phones:      []string{"+12223334444"},
+		})
+		So(calls, ShouldEqual, 2)
+		So(failed, ShouldResemble, SubscriptionUsers{Phones: []string{"+12223334444"}})
+
+		var apiErr *APIError
+		So(errors.As(err, &apiErr), ShouldEqual, true)
+		So(apiErr.Message, ShouldEqual, "Invalid subscription_group_id")
+	})
+
+	Convey("Rejects status changes app-boy wouldn't take", t, func() {
+		before()
+
+		users := SubscriptionUsers{ExternalIds: []string{"holah"}}
+		_, err := client.SetSubscriptionStatus("", SubscriptionSubscribed, users)
+		So(err, ShouldNotEqual, nil)
+		_, err = client.SetSubscriptionStatus("email-group", SubscriptionOptedIn, users)
+		So(err, ShouldNotEqual, nil)
+		_, err = client.SetSubscriptionStatus("email-group", SubscriptionSubscribed, SubscriptionUsers{})
+		So(err, ShouldNotEqual, nil)
+		_, err = client.SetSubscriptionStatus("sms-group", SubscriptionSubscribed, SubscriptionUsers{Phones: []string{"222-333-4444"}})
+		So(err, ShouldNotEqual, nil)
+	})
+
+	Convey("Can get users' statuses in a group", t, func() {
+		before()
+		defer after()
+
+		// Mock requests to app-boy
+		queries := []url.Values{}
+		MockSubscriptionStatusGetSuccess(func(_query url.Values) { queries = append(queries, _query) })
+
+		statuses, err := client.SubscriptionStatuses("email-group", SubscriptionUsers{ExternalIds: []string{"holah", "4900"}, Emails: []string{"a@example.com"}})
+		checkErr(err)
+		So(statuses, ShouldResemble, map[string]string{"holah": SubscriptionGroupSubscribed, "4900": SubscriptionGroupUnsubscribed})
+		So(len(queries), ShouldEqual, 2)
+		So(queries[0].Get("subscription_group_id"), ShouldEqual, "email-group")
+		So(queries[0]["external_id[]"], ShouldResemble, []string{"holah", "4900"})
+		So(queries[1]["email[]"], ShouldResemble, []string{"a@example.com"})
+
+		_, err = client.SubscriptionStatuses("", SubscriptionUsers{ExternalIds: []string{"holah"}})
+		So(err, ShouldNotEqual, nil)
+	})
+
+	Convey("Can get the groups users are in", t, func() {
+		before()
+		defer after()
+
+		// Mock request to app-boy
+		var query url.Values
+		MockSubscriptionUserStatusSuccess(func(_query url.Values) { query = _query })
+
+		users, err := client.UserSubscriptionGroups(SubscriptionUsers{ExternalIds: []string{"holah"}})
+		checkErr(err)
+		So(query["external_id[]"], ShouldResemble, []string{"holah"})
+		So(query.Get("app_group_id"), ShouldEqual, "foo")
+		So(len(users), ShouldEqual, 1)
+		So(users[0].ExternalId, ShouldEqual, "holah")
+		So(users[0].SubscriptionGroups, ShouldResemble, []SubscriptionGroup{
+			{Id: "email-group", Name: "Newsletter", Channel: "email", Status: SubscriptionGroupSubscribed},
+			{Id: "sms-group", Name: "Reminders", Channel: "sms", Status: SubscriptionGroupUnsubscribed},
+		})
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"net/http"
+	"net/url"
 
 	"github.com/jarcoal/httpmock"
 )
@@ -127,6 +128,185 @@ func MockTrackFailure(requestChecker func(map[string]interface{})) {
 	)
 }
 
+func MockUsersDeleteSuccess(requestChecker func(map[string]interface{})) {
+	mockEndpoint(UsersDeleteEndpoint, 201, "users_delete_res.json", requestChecker)
+}
+
+func MockUsersDeleteFailure(requestChecker func(map[string]interface{})) {
+	mockEndpoint(UsersDeleteEndpoint, 400, "users_res_err.json", requestChecker)
+}
+
+func MockUsersIdentifySuccess(requestChecker func(map[string]interface{})) {
+	mockEndpoint(UsersIdentifyEndpoint, 201, "aliases_res.json", requestChecker)
+}
+
+func MockUsersIdentifyFailure(requestChecker func(map[string]interface{})) {
+	mockEndpoint(UsersIdentifyEndpoint, 400, "users_res_err.json", requestChecker)
+}
+
+func MockNewAliasSuccess(requestChecker func(map[string]interface{})) {
+	mockEndpoint(NewAliasEndpoint, 201, "aliases_res.json", requestChecker)
+}
+
+func MockNewAliasFailure(requestChecker func(map[string]interface{})) {
+	mockEndpoint(NewAliasEndpoint, 400, "users_res_err.json", requestChecker)
+}
+
+func MockUpdateAliasSuccess(requestChecker func(map[string]interface{})) {
+	mockEndpoint(UpdateAliasEndpoint, 201, "aliases_res.json", requestChecker)
+}
+
+func MockUpdateAliasFailure(requestChecker func(map[string]interface{})) {
+	mockEndpoint(UpdateAliasEndpoint, 400, "users_res_err.json", requestChecker)
+}
+
+func MockUsersMergeSuccess(requestChecker func(map[string]interface{})) {
+	mockEndpoint(UsersMergeEndpoint, 202, "users_merge_res.json", requestChecker)
+}
+
+func MockUsersMergeFailure(requestChecker func(map[string]interface{})) {
+	mockEndpoint(UsersMergeEndpoint, 400, "users_res_err.json", requestChecker)
+}
+
+func MockExternalIdsRenameSuccess(requestChecker func(map[string]interface{})) {
+	mockEndpoint(ExternalIdsRenameEndpoint, 202, "external_ids_rename_res.json", requestChecker)
+}
+
+func MockExternalIdsRenameFailure(requestChecker func(map[string]interface{})) {
+	mockEndpoint(ExternalIdsRenameEndpoint, 400, "users_res_err.json", requestChecker)
+}
+
+func MockExternalIdsRemoveSuccess(requestChecker func(map[string]interface{})) {
+	mockEndpoint(ExternalIdsRemoveEndpoint, 202, "external_ids_remove_res.json", requestChecker)
+}
+
+func MockExternalIdsRemoveFailure(requestChecker func(map[string]interface{})) {
+	mockEndpoint(ExternalIdsRemoveEndpoint, 400, "users_res_err.json", requestChecker)
+}
+
+func MockCanvasTriggerSuccess(requestChecker func(map[string]interface{})) {
+	mockEndpoint(CanvasTriggerEndpoint, 201, "canvas_trigger_res.json", requestChecker)
+}
+
+func MockCanvasTriggerFailure(requestChecker func(map[string]interface{})) {
+	mockEndpoint(CanvasTriggerEndpoint, 400, "canvas_trigger_res_err.json", requestChecker)
+}
+
+func MockCampaignScheduleCreateSuccess(requestChecker func(map[string]interface{})) {
+	mockEndpoint(CampaignScheduleCreateEndpoint, 201, "schedule_create_res.json", requestChecker)
+}
+
+func MockCanvasScheduleCreateSuccess(requestChecker func(map[string]interface{})) {
+	mockEndpoint(CanvasScheduleCreateEndpoint, 201, "schedule_create_res.json", requestChecker)
+}
+
+func MockMessagesScheduleCreateSuccess(requestChecker func(map[string]interface{})) {
+	mockEndpoint(MessagesScheduleCreateEndpoint, 201, "schedule_create_res.json", requestChecker)
+}
+
+// endpoint is one of the schedule update or delete endpoints
+func MockScheduleChangeSuccess(endpoint string, requestChecker func(map[string]interface{})) {
+	mockEndpoint(endpoint, 201, "schedule_change_res.json", requestChecker)
+}
+
+// endpoint is any of the schedule endpoints
+func MockScheduleFailure(endpoint string, requestChecker func(map[string]interface{})) {
+	mockEndpoint(endpoint, 400, "schedule_res_err.json", requestChecker)
+}
+
+func MockMessagesSendSuccess(requestChecker func(map[string]interface{})) {
+	mockEndpoint(MessagesSendEndpoint, 201, "messages_send_res.json", requestChecker)
+}
+
+func MockMessagesSendFailure(requestChecker func(map[string]interface{})) {
+	mockEndpoint(MessagesSendEndpoint, 400, "messages_send_res_err.json", requestChecker)
+}
+
+func MockScheduledBroadcastsSuccess(queryChecker func(url.Values)) {
+	mockGetEndpoint(ScheduledBroadcastsEndpoint, 200, "scheduled_broadcasts_res.json", queryChecker)
+}
+
+func MockScheduledBroadcastsFailure(queryChecker func(url.Values)) {
+	mockGetEndpoint(ScheduledBroadcastsEndpoint, 400, "schedule_res_err.json", queryChecker)
+}
+
+func MockSendIdCreateSuccess(requestChecker func(map[string]interface{})) {
+	mockEndpoint(SendIdCreateEndpoint, 201, "send_id_create_res.json", requestChecker)
+}
+
+func MockSendIdCreateFailure(requestChecker func(map[string]interface{})) {
+	mockEndpoint(SendIdCreateEndpoint, 400, "sends_res_err.json", requestChecker)
+}
+
+func MockTransactionalEmailSuccess(campaignId string, requestChecker func(map[string]interface{})) {
+	mockEndpoint(TransactionalEmailEndpoint(campaignId), 201, "transactional_email_res.json", requestChecker)
+}
+
+func MockTransactionalEmailFailure(campaignId string, requestChecker func(map[string]interface{})) {
+	mockEndpoint(TransactionalEmailEndpoint(campaignId), 400, "transactional_email_res_err.json", requestChecker)
+}
+
+func MockSendDataSeriesSuccess(queryChecker func(url.Values)) {
+	mockGetEndpoint(SendDataSeriesEndpoint, 200, "send_data_series_res.json", queryChecker)
+}
+
+func MockSendDataSeriesFailure(queryChecker func(url.Values)) {
+	mockGetEndpoint(SendDataSeriesEndpoint, 400, "sends_res_err.json", queryChecker)
+}
+
+func MockSubscriptionStatusSetSuccess(requestChecker func(map[string]interface{})) {
+	mockEndpoint(SubscriptionStatusSetEndpoint, 201, "subscription_status_set_res.json", requestChecker)
+}
+
+func MockSubscriptionStatusSetFailure(requestChecker func(map[string]interface{})) {
+	mockEndpoint(SubscriptionStatusSetEndpoint, 400, "subscription_res_err.json", requestChecker)
+}
+
+func MockSubscriptionUserStatusSuccess(queryChecker func(url.Values)) {
+	mockGetEndpoint(SubscriptionUserStatusEndpoint, 200, "subscription_user_status_res.json", queryChecker)
+}
+
+func MockSubscriptionStatusGetSuccess(queryChecker func(url.Values)) {
+	mockGetEndpoint(SubscriptionStatusGetEndpoint, 200, "subscription_status_get_res.json", queryChecker)
+}
+
+// Responds to POSTs on endpoint with the fixture after handing the request
+// body to requestChecker
+func mockEndpoint(endpoint string, status int, fixture string, requestChecker func(map[string]interface{})) {
+	httpmock.Activate()
+	httpmock.RegisterResponder("POST", endpoint,
+		func(req *http.Request) (*http.Response, error) {
+			buf := new(bytes.Buffer)
+			buf.ReadFrom(req.Body)
+			_request := buf.String()
+
+			var request map[string]interface{}
+			err := json.Unmarshal([]byte(_request), &request)
+			checkErr(err)
+			requestChecker(request)
+
+			response := getFixtureWithPath(fixture)
+			resp := httpmock.NewStringResponse(status, response)
+			return resp, nil
+		},
+	)
+}
+
+// Responds to GETs on endpoint with the fixture after handing the query to
+// queryChecker
+func mockGetEndpoint(endpoint string, status int, fixture string, queryChecker func(url.Values)) {
+	httpmock.Activate()
+	httpmock.RegisterResponder("GET", endpoint,
+		func(req *http.Request) (*http.Response, error) {
+			queryChecker(req.URL.Query())
+
+			response := getFixtureWithPath(fixture)
+			resp := httpmock.NewStringResponse(status, response)
+			return resp, nil
+		},
+	)
+}
+
 func StopMocks() {
 	httpmock.DeactivateAndReset()
 }
@@ -0,0 +1,131 @@
+package gogo_boy
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+/*
+	----------------------------------------------------------------------
+	Raw requests for transactional emails
+	----------------------------------------------------------------------
+*/
+
+const transactionalEmailPathPrefix = "/transactional/v1/campaigns/"
+
+// The path depends on the transactional campaign being sent
+func TransactionalEmailPath(campaignId string) string {
+	return transactionalEmailPathPrefix + url.PathEscape(campaignId) + "/send"
+}
+
+// Full URL on the default host
+func TransactionalEmailEndpoint(campaignId string) string {
+	return DefaultBaseURL + TransactionalEmailPath(campaignId)
+}
+
+func isTransactionalEmailPath(path string) bool {
+	return strings.HasPrefix(path, transactionalEmailPathPrefix) && strings.HasSuffix(path, "/send")
+}
+
+// Send one email from a transactional campaign. The campaign id goes in the
+// path so it isn't part of the body.
+type RawTransactionalEmailRequest struct {
+	AppGroupId        string                    `json:"app_group_id,omitempty"`
+	ExternalSendId    string                    `json:"external_send_id,omitempty"` // Your own id, echoed back in postbacks
+	TriggerProperties map[string]interface{}    `json:"trigger_properties,omitempty"`
+	Recipient         RawTransactionalRecipient `json:"recipient"`
+}
+
+// Set ExternalId or UserAlias
+type RawTransactionalRecipient struct {
+	ExternalId string     `json:"external_user_id,omitempty"`
+	UserAlias  *UserAlias `json:"user_alias,omitempty"`
+
+	// Attributes to set on the user before sending, creating them if need be
+	Attributes         map[string]interface{} `json:"attributes,omitempty"`
+	SendToExistingOnly *bool                  `json:"send_to_existing_only,omitempty"`
+}
+
+type TransactionalEmailResponse struct {
+	DispatchId string `json:"dispatch_id"` // Matches the postbacks for this email
+	Status     string `json:"status"`
+	Message    string `json:"message"`
+}
+
+func RawPostTransactionalEmailRequest(campaignId string, rawReq *RawTransactionalEmailRequest) (*TransactionalEmailResponse, error) {
+	return RawPostTransactionalEmailRequestContext(context.Background(), nil, campaignId, rawReq)
+}
+
+// Same as RawPostTransactionalEmailRequest but sent over transport (nil for
+// the defaults) and aborts when ctx is done
+func RawPostTransactionalEmailRequestContext(ctx context.Context, transport *Transport, campaignId string, rawReq *RawTransactionalEmailRequest) (*TransactionalEmailResponse, error) {
+	res := &TransactionalEmailResponse{}
+	if err := transportOrDefault(transport).postJSON(ctx, "RawTransactionalEmailRequest", TransactionalEmailPath(campaignId), rawReq, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+/*
+	----------------------------------------------------------------------
+	Prettier version on Client
+	----------------------------------------------------------------------
+*/
+
+type TransactionalEmailRequest struct {
+	AppGroupId string
+	CampaignId string
+
+	ExternalSendId    string
+	TriggerProperties map[string]interface{}
+	Recipient         RawTransactionalRecipient
+
+	transport *Transport
+}
+
+func (c *Client) NewTransactionalEmailRequest(campaignId, externalId string) *TransactionalEmailRequest {
+	return &TransactionalEmailRequest{
+		AppGroupId: c.appGroupId,
+		CampaignId: campaignId,
+		Recipient:  RawTransactionalRecipient{ExternalId: externalId},
+		transport:  c.transport,
+	}
+}
+
+// Transactional emails can only be sent by external id or user alias
+func (c *Client) NewTransactionalEmailRequestFor(campaignId string, id Identifier) (*TransactionalEmailRequest, error) {
+	if err := id.validate(); err != nil {
+		return nil, err
+	}
+	if id.ExternalId == "" && id.UserAlias == nil {
+		return nil, fmt.Errorf("transactional email recipients can only be identified by external_id or user_alias (%s)", id)
+	}
+
+	ter := c.NewTransactionalEmailRequest(campaignId, id.ExternalId)
+	ter.Recipient.UserAlias = id.UserAlias
+	return ter, nil
+}
+
+func (ter *TransactionalEmailRequest) Send() (*TransactionalEmailResponse, error) {
+	return ter.SendContext(context.Background())
+}
+
+// Same as Send but aborts when ctx is done
+func (ter *TransactionalEmailRequest) SendContext(ctx context.Context) (*TransactionalEmailResponse, error) {
+	if ter.CampaignId == "" {
+		return nil, fmt.Errorf("TransactionalEmailRequest failed: no campaign_id was given")
+	}
+	if (ter.Recipient.ExternalId == "") == (ter.Recipient.UserAlias == nil) {
+		return nil, fmt.Errorf("TransactionalEmailRequest for the [AppBoyCampaign](campaign_id: %s) needs exactly one of an external_user_id or user_alias", ter.CampaignId)
+	}
+
+	return RawPostTransactionalEmailRequestContext(ctx, ter.transport, ter.CampaignId, &RawTransactionalEmailRequest{
+		AppGroupId:        ter.AppGroupId,
+		ExternalSendId:    ter.ExternalSendId,
+		TriggerProperties: ter.TriggerProperties,
+		Recipient:         ter.Recipient,
+	})
+}
@@ -0,0 +1,105 @@
+package gogo_boy
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTransactionalAPI(t *testing.T) {
+	var client *Client
+	before := func() {
+		client = NewClient("foo")
+	}
+
+	after := func() {
+		StopMocks()
+	}
+
+	Convey("Can send a transactional email", t, func() {
+		before()
+		defer after()
+
+		// Mock request to app-boy
+		var request map[string]interface{}
+		MockTransactionalEmailSuccess("my-campaign-id", func(_request map[string]interface{}) { request = _request })
+
+		ter := client.NewTransactionalEmailRequest("my-campaign-id", "holah")
+		ter.ExternalSendId = "order-1234"
+		ter.TriggerProperties = map[string]interface{}{"order_id": "1234"}
+		res, err := ter.Send()
+		checkErr(err)
+		So(res.DispatchId, ShouldEqual, "dispatch-3")
+		So(res.Status, ShouldEqual, "queued")
+		So(request, ShouldResemble, map[string]interface{}{
+			"app_group_id":       "foo",
+			"external_send_id":   "order-1234",
+			"trigger_properties": map[string]interface{}{"order_id": "1234"},
+			"recipient":          map[string]interface{}{"external_user_id": "holah"},
+		})
+	})
+
+	Convey("Can send a transactional email to a user alias", t, func() {
+		before()
+		defer after()
+
+		// Mock request to app-boy
+		var request map[string]interface{}
+		MockTransactionalEmailSuccess("my-campaign-id", func(_request map[string]interface{}) { request = _request })
+
+		ter, err := client.NewTransactionalEmailRequestFor("my-campaign-id", ByUserAlias("anon-123", "device"))
+		checkErr(err)
+		_, err = ter.Send()
+		checkErr(err)
+		So(request["recipient"], ShouldResemble, map[string]interface{}{
+			"user_alias": map[string]interface{}{"alias_name": "anon-123", "alias_label": "device"},
+		})
+
+		_, err = client.NewTransactionalEmailRequestFor("my-campaign-id", ByEmail("a@b.com"))
+		So(err, ShouldNotEqual, nil)
+	})
+
+	Convey("Rejects emails without a campaign or exactly one recipient", t, func() {
+		before()
+
+		_, err := client.NewTransactionalEmailRequest("", "holah").Send()
+		So(err, ShouldNotEqual, nil)
+		_, err = client.NewTransactionalEmailRequest("my-campaign-id", "").Send()
+		So(err, ShouldNotEqual, nil)
+
+		ter := client.NewTransactionalEmailRequest("my-campaign-id", "holah")
+		ter.Recipient.UserAlias = &UserAlias{AliasName: "anon-123", AliasLabel: "device"}
+		_, err = ter.Send()
+		So(err, ShouldNotEqual, nil)
+	})
+
+	Convey("Failed sends return an APIError", t, func() {
+		before()
+		defer after()
+
+		MockTransactionalEmailFailure("my-campaign-id", func(_request map[string]interface{}) {})
+
+		_, err := client.NewTransactionalEmailRequest("my-campaign-id", "holah").Send()
+
+		var apiErr *APIError
+		So(errors.As(err, &apiErr), ShouldEqual, true)
+		So(apiErr.StatusCode, ShouldEqual, 400)
+	})
+
+	Convey("Transactional emails aren't retried unless allowed", t, func() {
+		var calls int
+		rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			calls++
+			return newStubResponse(503, ""), nil
+		})
+
+		policy := DefaultRetryPolicy()
+		policy.MinBackoff = 0
+		client := NewClient("foo", WithRoundTripper(rt), WithRetryPolicy(policy))
+		_, err := client.NewTransactionalEmailRequest("my-campaign-id", "holah").Send()
+		So(err, ShouldNotEqual, nil)
+		So(calls, ShouldEqual, 1)
+	})
+}
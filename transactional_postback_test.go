@@ -0,0 +1,105 @@
+package gogo_boy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTransactionalPostback(t *testing.T) {
+	postback := func(handler http.Handler, method, body string, auth func(*http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/postbacks", strings.NewReader(body))
+		if auth != nil {
+			auth(req)
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	withAuth := func(username, password string) func(*http.Request) {
+		return func(req *http.Request) { req.SetBasicAuth(username, password) }
+	}
+
+	Convey("Parses verified postbacks into typed events", t, func() {
+		var event *TransactionalEvent
+		handler := NewTransactionalPostbackHandler("braze", "secret", func(_event *TransactionalEvent) { event = _event })
+
+		w := postback(handler, "POST", getFixtureWithPath("transactional_postback_bounced.json"), withAuth("braze", "secret"))
+		So(w.Code, ShouldEqual, 200)
+		So(event.EventType, ShouldEqual, TransactionalBounced)
+		So(event.DispatchId, ShouldEqual, "dispatch-3")
+		So(event.ExternalSendId, ShouldEqual, "order-1234")
+		So(event.KeyValuePairs, ShouldResemble, map[string]interface{}{"order_id": "1234"})
+		So(event.Metadata.ExternalId, ShouldEqual, "holah")
+		So(event.Metadata.CampaignApiId, ShouldEqual, "my-campaign-id")
+		So(event.Metadata.Reason, ShouldContainSubstring, "does not exist")
+		So(event.Metadata.BouncedAt.Equal(time.Date(2030, 1, 2, 15, 4, 7, 0, time.UTC)), ShouldEqual, true)
+		So(event.Metadata.DeliveredAt.IsZero(), ShouldEqual, true)
+	})
+
+	Convey("Rejects postbacks that fail verification or don't parse", t, func() {
+		var events int
+		handler := NewTransactionalPostbackHandler("braze", "secret", func(_event *TransactionalEvent) { events++ })
+		body := getFixtureWithPath("transactional_postback_bounced.json")
+
+		So(postback(handler, "POST", body, nil).Code, ShouldEqual, 401)
+		So(postback(handler, "POST", body, withAuth("braze", "wrong")).Code, ShouldEqual, 401)
+		So(postback(handler, "POST", body, withAuth("wrong", "secret")).Code, ShouldEqual, 401)
+		So(postback(handler, "GET", "", withAuth("braze", "secret")).Code, ShouldEqual, 405)
+		So(postback(handler, "POST", "{", withAuth("braze", "secret")).Code, ShouldEqual, 400)
+		So(postback(handler, "POST", `{"event_type":"sent"}`, withAuth("braze", "secret")).Code, ShouldEqual, 400)
+		So(events, ShouldEqual, 0)
+	})
+
+	Convey("Only accepts unauthenticated postbacks when asked to", t, func() {
+		var events int
+		handler := NewTransactionalPostbackHandler("", "", func(_event *TransactionalEvent) { events++ })
+		body := `{"event_type":"sent","dispatch_id":"dispatch-3"}`
+
+		So(postback(handler, "POST", body, nil).Code, ShouldEqual, 401)
+		So(postback(handler, "POST", body, withAuth("", "")).Code, ShouldEqual, 401)
+		So(events, ShouldEqual, 0)
+
+		handler.AllowUnauthenticated = true
+		So(postback(handler, "POST", body, nil).Code, ShouldEqual, 200)
+		So(events, ShouldEqual, 1)
+	})
+
+	Convey("Can hand events out on a channel", t, func() {
+		handler, events := NewTransactionalPostbackChan("braze", "secret", 1)
+
+		w := postback(handler, "POST", `{"event_type":"delivered","dispatch_id":"dispatch-3","metadata":{"delivered_at":"2030-01-02T15:04:07.000+00:00"}}`, withAuth("braze", "secret"))
+		So(w.Code, ShouldEqual, 200)
+
+		event := <-events
+		So(event.EventType, ShouldEqual, TransactionalDelivered)
+		So(event.Metadata.DeliveredAt.IsZero(), ShouldEqual, false)
+	})
+
+	Convey("Answers with a 503 right away when nobody reads the channel", t, func() {
+		handler, events := NewTransactionalPostbackChan("braze", "secret", 1)
+		body := `{"event_type":"sent","dispatch_id":"dispatch-3"}`
+		So(postback(handler, "POST", body, withAuth("braze", "secret")).Code, ShouldEqual, 200)
+
+		// The channel is full and nothing waits for room
+		So(postback(handler, "POST", body, withAuth("braze", "secret")).Code, ShouldEqual, 503)
+		So(len(events), ShouldEqual, 1)
+
+		// Or waits a little, and gets in once the channel is read
+		handler.SendTimeout = time.Second
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			<-events
+		}()
+		So(postback(handler, "POST", body, withAuth("braze", "secret")).Code, ShouldEqual, 200)
+		So(len(events), ShouldEqual, 1)
+
+		handler.SendTimeout = 10 * time.Millisecond
+		So(postback(handler, "POST", body, withAuth("braze", "secret")).Code, ShouldEqual, 503)
+	})
+}
@@ -0,0 +1,287 @@
+package gogo_boy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+/*
+	----------------------------------------------------------------------
+	Transport configuration shared by the raw and pretty APIs
+	----------------------------------------------------------------------
+*/
+
+const (
+	// The legacy app-boy host, still the default for backwards compatibility
+	DefaultBaseURL = "https://api.appboy.com"
+
+	// Regional REST clusters, pass one of these to WithBaseURL
+	ClusterUS01 = "https://rest.iad-01.braze.com"
+	ClusterUS02 = "https://rest.iad-02.braze.com"
+	ClusterUS03 = "https://rest.iad-03.braze.com"
+	ClusterUS04 = "https://rest.iad-04.braze.com"
+	ClusterUS05 = "https://rest.iad-05.braze.com"
+	ClusterUS06 = "https://rest.iad-06.braze.com"
+	ClusterUS08 = "https://rest.iad-08.braze.com"
+	ClusterEU01 = "https://rest.fra-01.braze.eu"
+	ClusterEU02 = "https://rest.fra-02.braze.eu"
+)
+
+// A transport decides where requests go and which http.Client carries them.
+// Every request built from a Client (and its AppClients) shares the Client's
+// transport.
+type Transport struct {
+	BaseURL    string
+	HTTPClient *http.Client  // If nil, a client is built from RoundTripper and Timeout
+	Timeout    time.Duration // Only used when HTTPClient is nil
+
+	// REST API key sent as a bearer token. When empty we fall back to the
+	// legacy model where the app_group_id in the body is the only credential.
+	APIKey string
+
+	RetryPolicy RetryPolicy // Zero value never retries
+
+	limiters map[string]*tokenBucket // By endpoint path, see WithRateLimit
+
+	roundTripper http.RoundTripper
+}
+
+type ClientOption func(*Transport)
+
+// Send requests to a different host, usually one of the Cluster* constants
+func WithBaseURL(baseURL string) ClientOption {
+	return func(t *Transport) {
+		t.BaseURL = baseURL
+	}
+}
+
+// Use your own http.Client, this takes precedence over WithRoundTripper
+// and WithTimeout
+func WithHTTPClient(client *http.Client) ClientOption {
+	return func(t *Transport) {
+		t.HTTPClient = client
+	}
+}
+
+func WithRoundTripper(rt http.RoundTripper) ClientOption {
+	return func(t *Transport) {
+		t.roundTripper = rt
+	}
+}
+
+// Authenticate with a REST API key, the app group id may be left empty
+func WithAPIKey(apiKey string) ClientOption {
+	return func(t *Transport) {
+		t.APIKey = apiKey
+	}
+}
+
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(t *Transport) {
+		t.Timeout = timeout
+	}
+}
+
+func NewTransport(opts ...ClientOption) *Transport {
+	t := &Transport{
+		BaseURL: DefaultBaseURL,
+		Timeout: timeoutDuration,
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
+}
+
+func (t *Transport) httpClient() *http.Client {
+	if t.HTTPClient != nil {
+		return t.HTTPClient
+	}
+
+	// A nil round tripper falls back to http.DefaultTransport at request time
+	// which is what httpmock hooks into
+	return &http.Client{
+		Transport: t.roundTripper,
+		Timeout:   t.Timeout,
+	}
+}
+
+func (t *Transport) url(path string) string {
+	return strings.TrimRight(t.BaseURL, "/") + path
+}
+
+// Post a JSON payload to path on this transport's host and return the
+// response body. name is the caller used to prefix errors. Cancelling ctx
+// aborts the request mid-flight, including while waiting to retry.
+func (t *Transport) post(ctx context.Context, name, path string, payload []byte) ([]byte, error) {
+	return t.do(ctx, "POST", name, path, nil, payload)
+}
+
+// Same as post but for GETs, which have a query instead of a payload
+func (t *Transport) get(ctx context.Context, name, path string, query url.Values) ([]byte, error) {
+	return t.do(ctx, "GET", name, path, query, nil)
+}
+
+// Same as post for a payload that does something twice when it's sent twice,
+// even though other payloads to path don't
+func (t *Transport) postNonIdempotent(ctx context.Context, name, path string, payload []byte) ([]byte, error) {
+	return t.retry(ctx, t.RetryPolicy.attemptsFor(path, true), "POST", name, path, nil, payload)
+}
+
+func (t *Transport) do(ctx context.Context, method, name, path string, query url.Values, payload []byte) ([]byte, error) {
+	return t.retry(ctx, t.RetryPolicy.attemptsFor(path, false), method, name, path, query, payload)
+}
+
+func (t *Transport) retry(ctx context.Context, attempts int, method, name, path string, query url.Values, payload []byte) ([]byte, error) {
+	for attempt := 1; ; attempt++ {
+		if err := t.takeRateLimit(ctx, path); err != nil {
+			return nil, fmt.Errorf("%s failed: %w", name, err)
+		}
+
+		body, err := t.doOnce(ctx, method, name, path, query, payload)
+		if err == nil || attempt >= attempts || ctx.Err() != nil || !t.RetryPolicy.shouldRetry(err) {
+			return body, err
+		}
+
+		timer := time.NewTimer(t.RetryPolicy.backoff(attempt, err))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return body, fmt.Errorf("%s failed while waiting to retry (%s): %w", name, err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// Marshals req, posts it like post and parses the response body (if any)
+// into res
+func (t *Transport) postJSON(ctx context.Context, name, path string, req, res interface{}) error {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("%s failed: %s", name, err)
+	}
+
+	body, err := t.post(ctx, name, path, payload)
+	if err != nil {
+		return err
+	}
+
+	return parseJSON(name, body, res)
+}
+
+// Gets path with query and parses the response body into res
+func (t *Transport) getJSON(ctx context.Context, name, path string, query url.Values, res interface{}) error {
+	body, err := t.get(ctx, name, path, query)
+	if err != nil {
+		return err
+	}
+
+	return parseJSON(name, body, res)
+}
+
+func parseJSON(name string, body []byte, res interface{}) error {
+	if res == nil || len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(body, res); err != nil {
+		return fmt.Errorf("%s failed to parse the response '%s': %s", name, body, err)
+	}
+
+	return nil
+}
+
+func (t *Transport) doOnce(ctx context.Context, method, name, path string, query url.Values, payload []byte) ([]byte, error) {
+	// Don't bother sending if the caller already gave up
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("%s failed: %w", name, err)
+	}
+
+	u := t.url(path)
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	// Create the request and make sure you set the content type
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewBuffer(payload))
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %s", name, err)
+	}
+	if payload != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+	if t.APIKey != "" {
+		req.Header.Add("Authorization", "Bearer "+t.APIKey)
+	}
+
+	// Execute request
+	resp, err := t.httpClient().Do(req)
+	if err != nil {
+		return nil, &networkError{name, err}
+	}
+
+	// Read body
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, &networkError{name, err}
+	}
+	t.observeRateLimit(path, resp.Header)
+
+	if !successStatus(method, path, resp.StatusCode) {
+		return body, newAPIError(name, method, req.URL.String(), resp.StatusCode, resp.Header, body)
+	}
+
+	return body, nil
+}
+
+// App-Boy returns a 201 if a post is successful, or a 202 from endpoints
+// that only queue the work. Gets just return a 200.
+func successStatus(method, path string, code int) bool {
+	switch {
+	case method == "GET":
+		return code == 200
+	case code == 202:
+		return asyncPaths[path]
+	}
+
+	return code == 201
+}
+
+// Endpoints that answer with a 202 once app-boy has queued the work
+var asyncPaths = map[string]bool{
+	UsersMergePath:        true,
+	ExternalIdsRenamePath: true,
+	ExternalIdsRemovePath: true,
+}
+
+// We never got a response out of app-boy
+type networkError struct {
+	op  string
+	err error
+}
+
+func (e *networkError) Error() string {
+	return fmt.Sprintf("%s failed: %s", e.op, e.err)
+}
+
+func (e *networkError) Unwrap() error {
+	return e.err
+}
+
+// Raw functions accept a nil transport to mean the package defaults
+func transportOrDefault(t *Transport) *Transport {
+	if t == nil {
+		return NewTransport()
+	}
+
+	return t
+}
@@ -0,0 +1,163 @@
+package gogo_boy
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// Lets us stand in for an http.RoundTripper without httpmock
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func newStubResponse(statusCode int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestTransport(t *testing.T) {
+	after := func() {
+		StopMocks()
+	}
+
+	Convey("Defaults to the app-boy host", t, func() {
+		transport := NewTransport()
+		So(transport.BaseURL, ShouldEqual, DefaultBaseURL)
+		So(transport.Timeout, ShouldEqual, timeoutDuration)
+		So(transport.url(TrackPath), ShouldEqual, TrackEndpoint)
+	})
+
+	Convey("Track requests from an app client use the client's base url", t, func() {
+		defer after()
+
+		var hits int
+		httpmock.Activate()
+		httpmock.RegisterResponder("POST", ClusterUS03+TrackPath, func(req *http.Request) (*http.Response, error) {
+			hits++
+			return httpmock.NewStringResponse(201, getFixtureWithPath("track_success_res.json")), nil
+		})
+
+		client := NewClient("foo", WithBaseURL(ClusterUS03+"/"))
+		_, err := client.NewAppClient("blah").NewTrackRequest("holah").Post()
+		So(err, ShouldEqual, nil)
+		So(hits, ShouldEqual, 1)
+	})
+
+	Convey("Campaign triggers use the client's round tripper", t, func() {
+		var url string
+		rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			url = req.URL.String()
+			return newStubResponse(201, getFixtureWithPath("triggered_campaign_res.json")), nil
+		})
+
+		client := NewClient("foo", WithBaseURL(ClusterEU01), WithRoundTripper(rt), WithTimeout(time.Second))
+		ctr := client.NewCampaignTriggerRequest("my-campaign-id")
+		ctr.AddRecipient("4900", nil)
+		err := ctr.Post()
+		So(err, ShouldEqual, nil)
+		So(url, ShouldEqual, ClusterEU01+CampaignTriggerPath)
+	})
+
+	Convey("A custom http.Client takes precedence", t, func() {
+		var calls int
+		httpClient := &http.Client{
+			Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				calls++
+				return newStubResponse(201, getFixtureWithPath("push_removal_res.json")), nil
+			}),
+		}
+
+		transport := NewTransport(WithHTTPClient(httpClient))
+		So(transport.httpClient(), ShouldEqual, httpClient)
+
+		err := RawPostDeletePushTokenRequestWithTransport(transport, &RawPushTokenDeleteRequest{
+			AppGroupId: "foo",
+			PushTokens: []RawPushTokenInfo{},
+		})
+		So(err, ShouldEqual, nil)
+		So(calls, ShouldEqual, 1)
+	})
+
+	Convey("Surfaces failures from a custom transport", t, func() {
+		rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return newStubResponse(500, "error"), nil
+		})
+
+		_, err := RawPostTrackRequestWithTransport(NewTransport(WithRoundTripper(rt)), &RawTrackRequest{AppGroupId: "foo"})
+		So(err, ShouldNotEqual, nil)
+		So(strings.Contains(err.Error(), "500"), ShouldEqual, true)
+	})
+
+	Convey("Sends the API key as a bearer token", t, func() {
+		var auth string
+		var body string
+		rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			auth = req.Header.Get("Authorization")
+			b, _ := ioutil.ReadAll(req.Body)
+			body = string(b)
+			return newStubResponse(201, getFixtureWithPath("track_success_res.json")), nil
+		})
+
+		appClient := NewAppClientFromConfig(ConfigureInfo{
+			AppId:   "blah",
+			APIKey:  "secret",
+			BaseURL: ClusterUS01,
+		}, WithRoundTripper(rt))
+		So(appClient.Transport().BaseURL, ShouldEqual, ClusterUS01)
+
+		_, err := appClient.NewTrackRequest("holah").Post()
+		So(err, ShouldEqual, nil)
+		So(auth, ShouldEqual, "Bearer secret")
+		So(strings.Contains(body, "app_group_id"), ShouldEqual, false)
+	})
+
+	Convey("Legacy clients only send the app group id", t, func() {
+		var auth string
+		var body string
+		rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			auth = req.Header.Get("Authorization")
+			b, _ := ioutil.ReadAll(req.Body)
+			body = string(b)
+			return newStubResponse(201, getFixtureWithPath("triggered_campaign_res.json")), nil
+		})
+
+		ctr := NewClient("foo", WithRoundTripper(rt)).NewCampaignTriggerRequest("my-campaign-id")
+		ctr.AddRecipient("4900", nil)
+		err := ctr.Post()
+		So(err, ShouldEqual, nil)
+		So(auth, ShouldEqual, "")
+		So(strings.Contains(body, `"app_group_id":"foo"`), ShouldEqual, true)
+	})
+
+	Convey("Gets send the API key and expect a 200", t, func() {
+		var req *http.Request
+		status := 200
+		rt := roundTripperFunc(func(_req *http.Request) (*http.Response, error) {
+			req = _req
+			return newStubResponse(status, getFixtureWithPath("scheduled_broadcasts_res.json")), nil
+		})
+
+		client := NewClient("", WithAPIKey("secret"), WithBaseURL(ClusterUS01), WithRoundTripper(rt))
+		_, err := client.ScheduledBroadcasts(time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC))
+		So(err, ShouldEqual, nil)
+		So(req.Method, ShouldEqual, "GET")
+		So(req.URL.String(), ShouldEqual, ClusterUS01+ScheduledBroadcastsPath+"?end_time=2030-01-02T00%3A00%3A00Z")
+		So(req.Header.Get("Authorization"), ShouldEqual, "Bearer secret")
+		So(req.Header.Get("Content-Type"), ShouldEqual, "")
+
+		status = 201
+		_, err = client.ScheduledBroadcasts(time.Now())
+		So(err, ShouldNotEqual, nil)
+	})
+}
@@ -0,0 +1,260 @@
+package gogo_boy
+
+import (
+	"context"
+	"fmt"
+)
+
+/*
+	----------------------------------------------------------------------
+	Raw requests for managing users and their aliases
+	----------------------------------------------------------------------
+*/
+
+const (
+	UsersDeletePath   = "/users/delete"
+	UsersIdentifyPath = "/users/identify"
+	NewAliasPath      = "/users/alias/new"
+	UpdateAliasPath   = "/users/alias/update"
+
+	// Full URLs on the default host
+	UsersDeleteEndpoint   = DefaultBaseURL + UsersDeletePath
+	UsersIdentifyEndpoint = DefaultBaseURL + UsersIdentifyPath
+	NewAliasEndpoint      = DefaultBaseURL + NewAliasPath
+	UpdateAliasEndpoint   = DefaultBaseURL + UpdateAliasPath
+)
+
+// App-boy won't take more users (or aliases) than this per request on any of
+// these endpoints
+const MaxUsersPerRequest = 50
+
+type RawUsersDeleteRequest struct {
+	AppGroupId  string      `json:"app_group_id,omitempty"`
+	ExternalIds []string    `json:"external_ids,omitempty"`
+	UserAliases []UserAlias `json:"user_aliases,omitempty"`
+	BrazeIds    []string    `json:"braze_ids,omitempty"`
+}
+
+type UsersDeleteResponse struct {
+	Deleted int             `json:"deleted"`
+	Message string          `json:"message"`
+	Errors  []ResponseError `json:"errors,omitempty"`
+}
+
+// Identifying merges the alias only profile into the external_id's
+type RawUsersIdentifyRequest struct {
+	AppGroupId        string                   `json:"app_group_id,omitempty"`
+	AliasesToIdentify []RawAliasIdentification `json:"aliases_to_identify"`
+}
+
+type RawAliasIdentification struct {
+	ExternalId string    `json:"external_id"`
+	UserAlias  UserAlias `json:"user_alias"`
+}
+
+type RawNewAliasRequest struct {
+	AppGroupId  string            `json:"app_group_id,omitempty"`
+	UserAliases []RawNewUserAlias `json:"user_aliases"`
+}
+
+// Without an ExternalId app-boy creates an alias only user
+type RawNewUserAlias struct {
+	ExternalId string `json:"external_id,omitempty"`
+	AliasName  string `json:"alias_name"`
+	AliasLabel string `json:"alias_label"`
+}
+
+type RawUpdateAliasRequest struct {
+	AppGroupId   string           `json:"app_group_id,omitempty"`
+	AliasUpdates []RawAliasUpdate `json:"alias_updates"`
+}
+
+type RawAliasUpdate struct {
+	AliasLabel   string `json:"alias_label"`
+	OldAliasName string `json:"old_alias_name"`
+	NewAliasName string `json:"new_alias_name"`
+}
+
+// What app-boy says about the identify and alias requests
+type AliasesResponse struct {
+	AliasesProcessed int             `json:"aliases_processed"`
+	Message          string          `json:"message"`
+	Errors           []ResponseError `json:"errors,omitempty"`
+}
+
+func RawPostUsersDeleteRequest(rawReq *RawUsersDeleteRequest) (*UsersDeleteResponse, error) {
+	return RawPostUsersDeleteRequestContext(context.Background(), nil, rawReq)
+}
+
+// Same as RawPostUsersDeleteRequest but sent over transport (nil for the
+// defaults) and aborts when ctx is done
+func RawPostUsersDeleteRequestContext(ctx context.Context, transport *Transport, rawReq *RawUsersDeleteRequest) (*UsersDeleteResponse, error) {
+	res := &UsersDeleteResponse{}
+	if err := transportOrDefault(transport).postJSON(ctx, "RawPostUsersDeleteRequest", UsersDeletePath, rawReq, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func RawPostUsersIdentifyRequest(rawReq *RawUsersIdentifyRequest) (*AliasesResponse, error) {
+	return RawPostUsersIdentifyRequestContext(context.Background(), nil, rawReq)
+}
+
+// Same as RawPostUsersIdentifyRequest but sent over transport (nil for the
+// defaults) and aborts when ctx is done
+func RawPostUsersIdentifyRequestContext(ctx context.Context, transport *Transport, rawReq *RawUsersIdentifyRequest) (*AliasesResponse, error) {
+	res := &AliasesResponse{}
+	if err := transportOrDefault(transport).postJSON(ctx, "RawPostUsersIdentifyRequest", UsersIdentifyPath, rawReq, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func RawPostNewAliasRequest(rawReq *RawNewAliasRequest) (*AliasesResponse, error) {
+	return RawPostNewAliasRequestContext(context.Background(), nil, rawReq)
+}
+
+// Same as RawPostNewAliasRequest but sent over transport (nil for the
+// defaults) and aborts when ctx is done
+func RawPostNewAliasRequestContext(ctx context.Context, transport *Transport, rawReq *RawNewAliasRequest) (*AliasesResponse, error) {
+	res := &AliasesResponse{}
+	if err := transportOrDefault(transport).postJSON(ctx, "RawPostNewAliasRequest", NewAliasPath, rawReq, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func RawPostUpdateAliasRequest(rawReq *RawUpdateAliasRequest) (*AliasesResponse, error) {
+	return RawPostUpdateAliasRequestContext(context.Background(), nil, rawReq)
+}
+
+// Same as RawPostUpdateAliasRequest but sent over transport (nil for the
+// defaults) and aborts when ctx is done
+func RawPostUpdateAliasRequestContext(ctx context.Context, transport *Transport, rawReq *RawUpdateAliasRequest) (*AliasesResponse, error) {
+	res := &AliasesResponse{}
+	if err := transportOrDefault(transport).postJSON(ctx, "RawPostUpdateAliasRequest", UpdateAliasPath, rawReq, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+/*
+	----------------------------------------------------------------------
+	Prettier versions on Client
+	----------------------------------------------------------------------
+*/
+
+// Permanently deletes the users. Users can't be deleted by email.
+func (c *Client) DeleteUsers(ids ...Identifier) (*UsersDeleteResponse, error) {
+	return c.DeleteUsersContext(context.Background(), ids...)
+}
+
+// Same as DeleteUsers but aborts when ctx is done
+func (c *Client) DeleteUsersContext(ctx context.Context, ids ...Identifier) (*UsersDeleteResponse, error) {
+	if err := checkUsersPerRequest("DeleteUsers", len(ids)); err != nil {
+		return nil, err
+	}
+
+	req := &RawUsersDeleteRequest{AppGroupId: c.appGroupId}
+	for _, id := range ids {
+		if err := id.validate(); err != nil {
+			return nil, fmt.Errorf("DeleteUsers failed: %s", err)
+		}
+
+		switch {
+		case id.ExternalId != "":
+			req.ExternalIds = append(req.ExternalIds, id.ExternalId)
+		case id.UserAlias != nil:
+			req.UserAliases = append(req.UserAliases, *id.UserAlias)
+		case id.BrazeId != "":
+			req.BrazeIds = append(req.BrazeIds, id.BrazeId)
+		default:
+			return nil, fmt.Errorf("DeleteUsers failed: users can't be deleted by email (%s)", id)
+		}
+	}
+
+	return RawPostUsersDeleteRequestContext(ctx, c.transport, req)
+}
+
+// Merges each alias only user into the user with the external id
+func (c *Client) IdentifyUsers(identifications ...RawAliasIdentification) (*AliasesResponse, error) {
+	return c.IdentifyUsersContext(context.Background(), identifications...)
+}
+
+// Same as IdentifyUsers but aborts when ctx is done
+func (c *Client) IdentifyUsersContext(ctx context.Context, identifications ...RawAliasIdentification) (*AliasesResponse, error) {
+	if err := checkUsersPerRequest("IdentifyUsers", len(identifications)); err != nil {
+		return nil, err
+	}
+	for _, identification := range identifications {
+		if identification.ExternalId == "" {
+			return nil, fmt.Errorf("IdentifyUsers failed: the alias %s/%s has no external_id to be merged into", identification.UserAlias.AliasLabel, identification.UserAlias.AliasName)
+		}
+		if err := ByUserAlias(identification.UserAlias.AliasName, identification.UserAlias.AliasLabel).validate(); err != nil {
+			return nil, fmt.Errorf("IdentifyUsers failed: %s", err)
+		}
+	}
+
+	return RawPostUsersIdentifyRequestContext(ctx, c.transport, &RawUsersIdentifyRequest{
+		AppGroupId:        c.appGroupId,
+		AliasesToIdentify: identifications,
+	})
+}
+
+func (c *Client) CreateAliases(aliases ...RawNewUserAlias) (*AliasesResponse, error) {
+	return c.CreateAliasesContext(context.Background(), aliases...)
+}
+
+// Same as CreateAliases but aborts when ctx is done
+func (c *Client) CreateAliasesContext(ctx context.Context, aliases ...RawNewUserAlias) (*AliasesResponse, error) {
+	if err := checkUsersPerRequest("CreateAliases", len(aliases)); err != nil {
+		return nil, err
+	}
+	for _, alias := range aliases {
+		if err := ByUserAlias(alias.AliasName, alias.AliasLabel).validate(); err != nil {
+			return nil, fmt.Errorf("CreateAliases failed: %s", err)
+		}
+	}
+
+	return RawPostNewAliasRequestContext(ctx, c.transport, &RawNewAliasRequest{
+		AppGroupId:  c.appGroupId,
+		UserAliases: aliases,
+	})
+}
+
+// Renames aliases, the label stays the same
+func (c *Client) UpdateAliases(updates ...RawAliasUpdate) (*AliasesResponse, error) {
+	return c.UpdateAliasesContext(context.Background(), updates...)
+}
+
+// Same as UpdateAliases but aborts when ctx is done
+func (c *Client) UpdateAliasesContext(ctx context.Context, updates ...RawAliasUpdate) (*AliasesResponse, error) {
+	if err := checkUsersPerRequest("UpdateAliases", len(updates)); err != nil {
+		return nil, err
+	}
+	for _, update := range updates {
+		if update.AliasLabel == "" || update.OldAliasName == "" || update.NewAliasName == "" {
+			return nil, fmt.Errorf("UpdateAliases failed: alias updates need a label, old name and new name")
+		}
+	}
+
+	return RawPostUpdateAliasRequestContext(ctx, c.transport, &RawUpdateAliasRequest{
+		AppGroupId:   c.appGroupId,
+		AliasUpdates: updates,
+	})
+}
+
+func checkUsersPerRequest(name string, n int) error {
+	if n == 0 {
+		return fmt.Errorf("%s failed: no users were given", name)
+	}
+	if n > MaxUsersPerRequest {
+		return fmt.Errorf("%s failed: %d users were given which exceeds the maximum of %d per request", name, n, MaxUsersPerRequest)
+	}
+
+	return nil
+}
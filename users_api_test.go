@@ -0,0 +1,118 @@
+package gogo_boy
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUsersAPI(t *testing.T) {
+	var client *Client
+	before := func() {
+		client = NewClient("foo")
+	}
+
+	after := func() {
+		StopMocks()
+	}
+
+	Convey("Can delete users by any identifier but email", t, func() {
+		before()
+		defer after()
+
+		// Mock request to app-boy
+		var request map[string]interface{}
+		MockUsersDeleteSuccess(func(_request map[string]interface{}) { request = _request })
+
+		res, err := client.DeleteUsers(ByExternalId("holah"), ByUserAlias("anon-123", "device"), ByBrazeId("5cd1"))
+		checkErr(err)
+		So(res.Deleted, ShouldEqual, 2)
+		So(request["app_group_id"], ShouldEqual, "foo")
+		So(request["external_ids"], ShouldResemble, []interface{}{"holah"})
+		So(request["user_aliases"], ShouldResemble, []interface{}{map[string]interface{}{"alias_name": "anon-123", "alias_label": "device"}})
+		So(request["braze_ids"], ShouldResemble, []interface{}{"5cd1"})
+
+		_, err = client.DeleteUsers(ByEmail("foo@example.com"))
+		So(err, ShouldNotEqual, nil)
+	})
+
+	Convey("Can identify alias only users", t, func() {
+		before()
+		defer after()
+
+		// Mock request to app-boy
+		var request map[string]interface{}
+		MockUsersIdentifySuccess(func(_request map[string]interface{}) { request = _request })
+
+		res, err := client.IdentifyUsers(RawAliasIdentification{
+			ExternalId: "holah",
+			UserAlias:  UserAlias{AliasName: "anon-123", AliasLabel: "device"},
+		})
+		checkErr(err)
+		So(res.AliasesProcessed, ShouldEqual, 1)
+		So(request["aliases_to_identify"], ShouldResemble, []interface{}{map[string]interface{}{
+			"external_id": "holah",
+			"user_alias":  map[string]interface{}{"alias_name": "anon-123", "alias_label": "device"},
+		}})
+
+		_, err = client.IdentifyUsers(RawAliasIdentification{UserAlias: UserAlias{AliasName: "anon-123", AliasLabel: "device"}})
+		So(err, ShouldNotEqual, nil)
+	})
+
+	Convey("Can create and update aliases", t, func() {
+		before()
+		defer after()
+
+		// Mock requests to app-boy
+		var created, updated map[string]interface{}
+		MockNewAliasSuccess(func(_request map[string]interface{}) { created = _request })
+		MockUpdateAliasSuccess(func(_request map[string]interface{}) { updated = _request })
+
+		_, err := client.CreateAliases(RawNewUserAlias{AliasName: "anon-123", AliasLabel: "device"})
+		checkErr(err)
+		So(created["user_aliases"], ShouldResemble, []interface{}{map[string]interface{}{"alias_name": "anon-123", "alias_label": "device"}})
+
+		_, err = client.UpdateAliases(RawAliasUpdate{AliasLabel: "device", OldAliasName: "anon-123", NewAliasName: "anon-456"})
+		checkErr(err)
+		So(updated["alias_updates"], ShouldResemble, []interface{}{map[string]interface{}{
+			"alias_label":    "device",
+			"old_alias_name": "anon-123",
+			"new_alias_name": "anon-456",
+		}})
+
+		_, err = client.CreateAliases(RawNewUserAlias{AliasName: "anon-123"})
+		So(err, ShouldNotEqual, nil)
+		_, err = client.UpdateAliases(RawAliasUpdate{AliasLabel: "device", OldAliasName: "anon-123"})
+		So(err, ShouldNotEqual, nil)
+	})
+
+	Convey("Returns app-boy's errors", t, func() {
+		before()
+		defer after()
+
+		MockNewAliasFailure(func(map[string]interface{}) {})
+
+		_, err := RawPostNewAliasRequestContext(context.Background(), nil, &RawNewAliasRequest{
+			UserAliases: []RawNewUserAlias{{AliasLabel: "device"}},
+		})
+		var apiErr *APIError
+		So(errors.As(err, &apiErr), ShouldEqual, true)
+		So(apiErr.StatusCode, ShouldEqual, 400)
+		So(apiErr.Errors[0].Type, ShouldEqual, "user_aliases must contain an alias_name")
+	})
+
+	Convey("Won't send more users than app-boy takes per request", t, func() {
+		before()
+
+		ids := []Identifier{}
+		for i := 0; i <= MaxUsersPerRequest; i++ {
+			ids = append(ids, ByBrazeId("5cd1"))
+		}
+		_, err := client.DeleteUsers(ids...)
+		So(err, ShouldNotEqual, nil)
+		_, err = client.DeleteUsers()
+		So(err, ShouldNotEqual, nil)
+	})
+}
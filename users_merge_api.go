@@ -0,0 +1,331 @@
+package gogo_boy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+/*
+	----------------------------------------------------------------------
+	Raw requests for merging users and migrating external ids
+	----------------------------------------------------------------------
+*/
+
+const (
+	UsersMergePath        = "/users/merge"
+	ExternalIdsRenamePath = "/users/external_ids/rename"
+	ExternalIdsRemovePath = "/users/external_ids/remove"
+
+	// Full URLs on the default host
+	UsersMergeEndpoint        = DefaultBaseURL + UsersMergePath
+	ExternalIdsRenameEndpoint = DefaultBaseURL + ExternalIdsRenamePath
+	ExternalIdsRemoveEndpoint = DefaultBaseURL + ExternalIdsRemovePath
+)
+
+type RawUsersMergeRequest struct {
+	AppGroupId   string                `json:"app_group_id,omitempty"`
+	MergeUpdates []RawUsersMergeUpdate `json:"merge_updates"`
+}
+
+// The user to merge is folded into the user to keep and then deleted
+type RawUsersMergeUpdate struct {
+	IdentifierToMerge RawMergeIdentifier `json:"identifier_to_merge"`
+	IdentifierToKeep  RawMergeIdentifier `json:"identifier_to_keep"`
+}
+
+// Set one of ExternalId, UserAlias or Email. Since many users can share an
+// email, Prioritization says which one is meant (e.g. "most_recently_updated").
+type RawMergeIdentifier struct {
+	ExternalId     string     `json:"external_id,omitempty"`
+	UserAlias      *UserAlias `json:"user_alias,omitempty"`
+	Email          string     `json:"email,omitempty"`
+	Prioritization []string   `json:"prioritization,omitempty"`
+}
+
+// Errors' Index points into the request's MergeUpdates
+type UsersMergeResponse struct {
+	Message string          `json:"message"`
+	Errors  []ResponseError `json:"errors,omitempty"`
+}
+
+type RawExternalIdRenameRequest struct {
+	AppGroupId        string                `json:"app_group_id,omitempty"`
+	ExternalIdRenames []RawExternalIdRename `json:"external_id_renames"`
+}
+
+// The old id keeps working until it's removed with /users/external_ids/remove
+type RawExternalIdRename struct {
+	CurrentExternalId string `json:"current_external_id"`
+	NewExternalId     string `json:"new_external_id"`
+}
+
+type ExternalIdRenameResponse struct {
+	Message      string                  `json:"message"`
+	ExternalIds  []string                `json:"external_ids"` // The new ids that were renamed
+	RenameErrors []ExternalIdRenameError `json:"rename_errors,omitempty"`
+}
+
+// App-boy sends these as ["message", {rename}] pairs
+type ExternalIdRenameError struct {
+	Message string
+	Rename  RawExternalIdRename
+}
+
+func (e *ExternalIdRenameError) UnmarshalJSON(data []byte) error {
+	pair := []interface{}{&e.Message, &e.Rename}
+	return json.Unmarshal(data, &pair)
+}
+
+type RawExternalIdRemoveRequest struct {
+	AppGroupId  string   `json:"app_group_id,omitempty"`
+	ExternalIds []string `json:"external_ids"`
+}
+
+type ExternalIdRemoveResponse struct {
+	Message       string                   `json:"message"`
+	RemovedIds    []string                 `json:"removed_ids"`
+	RemovalErrors []ExternalIdRemovalError `json:"removal_errors,omitempty"`
+}
+
+// App-boy sends these as ["message", "external id"] pairs
+type ExternalIdRemovalError struct {
+	Message    string
+	ExternalId string
+}
+
+func (e *ExternalIdRemovalError) UnmarshalJSON(data []byte) error {
+	pair := []interface{}{&e.Message, &e.ExternalId}
+	return json.Unmarshal(data, &pair)
+}
+
+func RawPostUsersMergeRequest(rawReq *RawUsersMergeRequest) (*UsersMergeResponse, error) {
+	return RawPostUsersMergeRequestContext(context.Background(), nil, rawReq)
+}
+
+// Same as RawPostUsersMergeRequest but sent over transport (nil for the
+// defaults) and aborts when ctx is done
+func RawPostUsersMergeRequestContext(ctx context.Context, transport *Transport, rawReq *RawUsersMergeRequest) (*UsersMergeResponse, error) {
+	res := &UsersMergeResponse{}
+	if err := transportOrDefault(transport).postJSON(ctx, "RawPostUsersMergeRequest", UsersMergePath, rawReq, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func RawPostExternalIdRenameRequest(rawReq *RawExternalIdRenameRequest) (*ExternalIdRenameResponse, error) {
+	return RawPostExternalIdRenameRequestContext(context.Background(), nil, rawReq)
+}
+
+// Same as RawPostExternalIdRenameRequest but sent over transport (nil for the
+// defaults) and aborts when ctx is done
+func RawPostExternalIdRenameRequestContext(ctx context.Context, transport *Transport, rawReq *RawExternalIdRenameRequest) (*ExternalIdRenameResponse, error) {
+	res := &ExternalIdRenameResponse{}
+	if err := transportOrDefault(transport).postJSON(ctx, "RawPostExternalIdRenameRequest", ExternalIdsRenamePath, rawReq, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func RawPostExternalIdRemoveRequest(rawReq *RawExternalIdRemoveRequest) (*ExternalIdRemoveResponse, error) {
+	return RawPostExternalIdRemoveRequestContext(context.Background(), nil, rawReq)
+}
+
+// Same as RawPostExternalIdRemoveRequest but sent over transport (nil for the
+// defaults) and aborts when ctx is done
+func RawPostExternalIdRemoveRequestContext(ctx context.Context, transport *Transport, rawReq *RawExternalIdRemoveRequest) (*ExternalIdRemoveResponse, error) {
+	res := &ExternalIdRemoveResponse{}
+	if err := transportOrDefault(transport).postJSON(ctx, "RawPostExternalIdRemoveRequest", ExternalIdsRemovePath, rawReq, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+/*
+	----------------------------------------------------------------------
+	Prettier versions on Client, which take any number of users
+	----------------------------------------------------------------------
+*/
+
+// Merge is folded into Keep. Users can't be merged by braze_id and users
+// merged by email are picked by most recent update.
+type UserMerge struct {
+	Merge Identifier
+	Keep  Identifier
+}
+
+// Sends the merges in requests of MaxUsersPerRequest one after the other.
+// Merges in a request that failed are listed in the response's Errors like
+// the ones app-boy rejected, with Index pointing into merges. The error
+// summarises the failed requests.
+func (c *Client) MergeUsers(merges ...UserMerge) (*UsersMergeResponse, error) {
+	return c.MergeUsersContext(context.Background(), merges...)
+}
+
+// Same as MergeUsers but aborts when ctx is done, merges that weren't sent
+// yet fail with the context's error
+func (c *Client) MergeUsersContext(ctx context.Context, merges ...UserMerge) (*UsersMergeResponse, error) {
+	updates := []RawUsersMergeUpdate{}
+	for _, merge := range merges {
+		toMerge, err := rawMergeIdentifier(merge.Merge)
+		if err != nil {
+			return nil, fmt.Errorf("MergeUsers failed: %s", err)
+		}
+		toKeep, err := rawMergeIdentifier(merge.Keep)
+		if err != nil {
+			return nil, fmt.Errorf("MergeUsers failed: %s", err)
+		}
+		updates = append(updates, RawUsersMergeUpdate{IdentifierToMerge: toMerge, IdentifierToKeep: toKeep})
+	}
+
+	ranges := chunkRanges(len(updates), MaxUsersPerRequest)
+	responses := make([]*UsersMergeResponse, len(ranges))
+	errs := postChunks(ctx, len(ranges), 1, func(ctx context.Context, i int) error {
+		var err error
+		responses[i], err = RawPostUsersMergeRequestContext(ctx, c.transport, &RawUsersMergeRequest{
+			AppGroupId:   c.appGroupId,
+			MergeUpdates: updates[ranges[i][0]:ranges[i][1]],
+		})
+		return err
+	})
+
+	res := &UsersMergeResponse{Message: "success"}
+	for i, r := range ranges {
+		if errs[i] != nil {
+			for j := r[0]; j < r[1]; j++ {
+				res.Errors = append(res.Errors, ResponseError{Type: errs[i].Error(), InputArray: "merge_updates", Index: j})
+			}
+			continue
+		}
+
+		for _, re := range responses[i].Errors {
+			re.Index += r[0]
+			res.Errors = append(res.Errors, re)
+		}
+	}
+
+	return res, chunksError("MergeUsers", errs)
+}
+
+// Sends the renames in requests of MaxUsersPerRequest one after the other.
+// Renames in a request that failed are listed in the response's
+// RenameErrors like the ones app-boy rejected. The error summarises the failed
+// requests.
+func (c *Client) RenameExternalIds(renames ...RawExternalIdRename) (*ExternalIdRenameResponse, error) {
+	return c.RenameExternalIdsContext(context.Background(), renames...)
+}
+
+// Same as RenameExternalIds but aborts when ctx is done, renames that weren't
+// sent yet fail with the context's error
+func (c *Client) RenameExternalIdsContext(ctx context.Context, renames ...RawExternalIdRename) (*ExternalIdRenameResponse, error) {
+	for _, rename := range renames {
+		if rename.CurrentExternalId == "" || rename.NewExternalId == "" {
+			return nil, fmt.Errorf("RenameExternalIds failed: renames need both a current and a new external id")
+		}
+	}
+
+	ranges := chunkRanges(len(renames), MaxUsersPerRequest)
+	responses := make([]*ExternalIdRenameResponse, len(ranges))
+	errs := postChunks(ctx, len(ranges), 1, func(ctx context.Context, i int) error {
+		var err error
+		responses[i], err = RawPostExternalIdRenameRequestContext(ctx, c.transport, &RawExternalIdRenameRequest{
+			AppGroupId:        c.appGroupId,
+			ExternalIdRenames: renames[ranges[i][0]:ranges[i][1]],
+		})
+		return err
+	})
+
+	res := &ExternalIdRenameResponse{Message: "success", ExternalIds: []string{}}
+	for i, r := range ranges {
+		if errs[i] != nil {
+			for _, rename := range renames[r[0]:r[1]] {
+				res.RenameErrors = append(res.RenameErrors, ExternalIdRenameError{Message: errs[i].Error(), Rename: rename})
+			}
+			continue
+		}
+
+		res.ExternalIds = append(res.ExternalIds, responses[i].ExternalIds...)
+		res.RenameErrors = append(res.RenameErrors, responses[i].RenameErrors...)
+	}
+
+	return res, chunksError("RenameExternalIds", errs)
+}
+
+// Removes external ids left behind by RenameExternalIds, the current ids
+// can't be removed. Batched like RenameExternalIds.
+func (c *Client) RemoveExternalIds(externalIds ...string) (*ExternalIdRemoveResponse, error) {
+	return c.RemoveExternalIdsContext(context.Background(), externalIds...)
+}
+
+// Same as RemoveExternalIds but aborts when ctx is done, ids that weren't
+// sent yet fail with the context's error
+func (c *Client) RemoveExternalIdsContext(ctx context.Context, externalIds ...string) (*ExternalIdRemoveResponse, error) {
+	ranges := chunkRanges(len(externalIds), MaxUsersPerRequest)
+	responses := make([]*ExternalIdRemoveResponse, len(ranges))
+	errs := postChunks(ctx, len(ranges), 1, func(ctx context.Context, i int) error {
+		var err error
+		responses[i], err = RawPostExternalIdRemoveRequestContext(ctx, c.transport, &RawExternalIdRemoveRequest{
+			AppGroupId:  c.appGroupId,
+			ExternalIds: externalIds[ranges[i][0]:ranges[i][1]],
+		})
+		return err
+	})
+
+	res := &ExternalIdRemoveResponse{Message: "success", RemovedIds: []string{}}
+	for i, r := range ranges {
+		if errs[i] != nil {
+			for _, externalId := range externalIds[r[0]:r[1]] {
+				res.RemovalErrors = append(res.RemovalErrors, ExternalIdRemovalError{Message: errs[i].Error(), ExternalId: externalId})
+			}
+			continue
+		}
+
+		res.RemovedIds = append(res.RemovedIds, responses[i].RemovedIds...)
+		res.RemovalErrors = append(res.RemovalErrors, responses[i].RemovalErrors...)
+	}
+
+	return res, chunksError("RemoveExternalIds", errs)
+}
+
+func rawMergeIdentifier(id Identifier) (RawMergeIdentifier, error) {
+	if err := id.validate(); err != nil {
+		return RawMergeIdentifier{}, err
+	}
+	if id.BrazeId != "" {
+		return RawMergeIdentifier{}, fmt.Errorf("users can't be merged by braze_id (%s)", id)
+	}
+
+	raw := RawMergeIdentifier{
+		ExternalId: id.ExternalId,
+		UserAlias:  id.UserAlias,
+		Email:      id.Email,
+	}
+	if id.Email != "" {
+		raw.Prioritization = []string{"most_recently_updated"}
+	}
+
+	return raw, nil
+}
+
+// Summarises the chunks that failed, nil if none did
+func chunksError(name string, errs []error) error {
+	failed := 0
+	var first error
+	for _, err := range errs {
+		if err != nil {
+			failed++
+			if first == nil {
+				first = err
+			}
+		}
+	}
+
+	if failed == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%s failed for %d of %d requests, the first error was: %w", name, failed, len(errs), first)
+}
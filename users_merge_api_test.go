@@ -0,0 +1,120 @@
+package gogo_boy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUsersMergeAPI(t *testing.T) {
+	var client *Client
+	before := func() {
+		client = NewClient("foo")
+	}
+
+	after := func() {
+		StopMocks()
+	}
+
+	Convey("Can merge users", t, func() {
+		before()
+		defer after()
+
+		// Mock request to app-boy
+		var request map[string]interface{}
+		MockUsersMergeSuccess(func(_request map[string]interface{}) { request = _request })
+
+		res, err := client.MergeUsers(
+			UserMerge{Merge: ByExternalId("old"), Keep: ByExternalId("new")},
+			UserMerge{Merge: ByUserAlias("anon-123", "device"), Keep: ByEmail("foo@example.com")},
+		)
+		checkErr(err)
+		So(len(res.Errors), ShouldEqual, 0)
+		So(request["merge_updates"], ShouldResemble, []interface{}{
+			map[string]interface{}{
+				"identifier_to_merge": map[string]interface{}{"external_id": "old"},
+				"identifier_to_keep":  map[string]interface{}{"external_id": "new"},
+			},
+			map[string]interface{}{
+				"identifier_to_merge": map[string]interface{}{"user_alias": map[string]interface{}{"alias_name": "anon-123", "alias_label": "device"}},
+				"identifier_to_keep":  map[string]interface{}{"email": "foo@example.com", "prioritization": []interface{}{"most_recently_updated"}},
+			},
+		})
+
+		_, err = client.MergeUsers(UserMerge{Merge: ByBrazeId("5cd1"), Keep: ByExternalId("new")})
+		So(err, ShouldNotEqual, nil)
+	})
+
+	Convey("Reports rename and removal errors per external id", t, func() {
+		before()
+		defer after()
+
+		// Mock requests to app-boy
+		var renamed, removed map[string]interface{}
+		MockExternalIdsRenameSuccess(func(_request map[string]interface{}) { renamed = _request })
+		MockExternalIdsRemoveSuccess(func(_request map[string]interface{}) { removed = _request })
+
+		renameRes, err := client.RenameExternalIds(
+			RawExternalIdRename{CurrentExternalId: "old-1", NewExternalId: "new-1"},
+			RawExternalIdRename{CurrentExternalId: "missing", NewExternalId: "new-2"},
+		)
+		checkErr(err)
+		So(len(renamed["external_id_renames"].([]interface{})), ShouldEqual, 2)
+		So(renameRes.ExternalIds, ShouldResemble, []string{"new-1"})
+		So(renameRes.RenameErrors, ShouldResemble, []ExternalIdRenameError{{
+			Message: "current_external_id does not exist",
+			Rename:  RawExternalIdRename{CurrentExternalId: "missing", NewExternalId: "new-2"},
+		}})
+
+		removeRes, err := client.RemoveExternalIds("old-1", "current-1")
+		checkErr(err)
+		So(removed["external_ids"], ShouldResemble, []interface{}{"old-1", "current-1"})
+		So(removeRes.RemovedIds, ShouldResemble, []string{"old-1"})
+		So(removeRes.RemovalErrors, ShouldResemble, []ExternalIdRemovalError{{Message: "external_id is the current external_id", ExternalId: "current-1"}})
+	})
+
+	Convey("Splits large batches and reports the items of failed requests", t, func() {
+		var calls int
+		client := NewClient("foo", WithRoundTripper(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			calls++
+			var request RawUsersMergeRequest
+			checkErr(json.NewDecoder(req.Body).Decode(&request))
+			if calls == 2 {
+				return newStubResponse(400, `{"message":"An error message"}`), nil
+			}
+			return newStubResponse(202, `{"message":"success","errors":[{"type":"no such user","input_array":"merge_updates","index":1}]}`), nil
+		})))
+
+		merges := []UserMerge{}
+		for i := 0; i < 120; i++ {
+			merges = append(merges, UserMerge{Merge: ByExternalId(fmt.Sprint(i)), Keep: ByExternalId("keep")})
+		}
+
+		res, err := client.MergeUsers(merges...)
+		So(err, ShouldNotEqual, nil)
+		So(err.Error(), ShouldContainSubstring, "1 of 3 requests")
+		So(calls, ShouldEqual, 3)
+
+		// One from each request that went through plus the whole second request
+		So(len(res.Errors), ShouldEqual, 2+50)
+		So(res.Errors[0].Index, ShouldEqual, 1)
+		So(res.Errors[1].Index, ShouldEqual, 50)
+		So(res.Errors[50].Index, ShouldEqual, 99)
+		So(res.Errors[51].Index, ShouldEqual, 101)
+		So(res.Errors[51].Type, ShouldEqual, "no such user")
+	})
+
+	Convey("Only the async endpoints may answer with a 202", t, func() {
+		client := NewClient("foo", WithRoundTripper(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return newStubResponse(202, `{"message":"success"}`), nil
+		})))
+
+		_, err := client.RemoveExternalIds("old-1")
+		checkErr(err)
+		_, err = client.DeleteUsers(ByExternalId("holah"))
+		So(err, ShouldNotEqual, nil)
+	})
+}
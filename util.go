@@ -1,10 +1,12 @@
 package gogo_boy
 
 import (
+	"context"
 	"io/ioutil"
 	"path"
 	"runtime"
 	"strings"
+	"sync"
 )
 
 func getFixtureWithPath(_path string) string {
@@ -36,3 +38,42 @@ func checkErr(err error) {
 		panic(err)
 	}
 }
+
+// Splits n items into [start, end) ranges of at most size
+func chunkRanges(n, size int) [][2]int {
+	ranges := [][2]int{}
+	for start := 0; start < n; start += size {
+		end := start + size
+		if end > n {
+			end = n
+		}
+		ranges = append(ranges, [2]int{start, end})
+	}
+
+	return ranges
+}
+
+// Calls post for chunks 0..n-1 with at most concurrency calls at once and
+// returns each chunk's error
+func postChunks(ctx context.Context, n, concurrency int, post func(ctx context.Context, i int) error) []error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	errs := make([]error, n)
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			errs[i] = post(ctx, i)
+		}(i)
+	}
+	wg.Wait()
+
+	return errs
+}